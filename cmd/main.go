@@ -13,6 +13,10 @@ import (
 	"github.com/officiallysidsingh/ecom-server/db"
 	"github.com/officiallysidsingh/ecom-server/internal/config"
 	"github.com/officiallysidsingh/ecom-server/internal/router"
+	"github.com/officiallysidsingh/ecom-server/internal/storage"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+	"github.com/officiallysidsingh/ecom-server/internal/workers"
 )
 
 func main() {
@@ -33,11 +37,40 @@ func main() {
 	// Close DB connection on shutdown
 	defer db.CloseDB(dbConn)
 
+	// Load JWT signing and verification keys
+	keys, err := utils.LoadKeySet(envConfig.JWT_KEYS_DIR, envConfig.JWT_SIGNING_KID)
+	if err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+
+	// Init storage for uploaded files
+	files, err := storage.NewLocalBackend(envConfig.STORAGE_DIR, envConfig.STORAGE_URL)
+	if err != nil {
+		log.Fatalf("Error initializing file storage: %v", err)
+	}
+
+	// Start the background thumbnail worker
+	thumbnailConfig, err := config.NewThumbnailConfig(envConfig.THUMBNAIL_SIZES)
+	if err != nil {
+		log.Fatalf("Error reading thumbnail config: %v", err)
+	}
+	thumbnails := workers.NewThumbnailWorker(store.NewImageStore(dbConn), files, thumbnailConfig)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+	go func() {
+		thumbnails.Run(workerCtx)
+		close(workersDone)
+	}()
+
 	// Setup Router & Middlewares
-	r := router.Setup(dbConn, envConfig)
+	r := router.Setup(dbConn, keys, files, thumbnails)
 
 	// Start server with graceful shutdown
 	startServerWithGracefulShutdown(r, envConfig.SERVER_PORT)
+
+	// Let the workers finish before the DB connection closes
+	stopWorkers()
+	<-workersDone
 }
 
 func startServerWithGracefulShutdown(handler http.Handler, port string) {
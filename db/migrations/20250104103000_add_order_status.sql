@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Add status to orders table
+ALTER TABLE orders
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'paid', 'fulfilled', 'shipped', 'delivered', 'cancelled', 'refunded'));
+
+-- Create order_status_history table
+CREATE TABLE order_status_history (
+    history_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    changed_by UUID REFERENCES users(user_id) ON DELETE SET NULL,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id);
+
+-- Record the initial status of existing orders
+INSERT INTO order_status_history (history_id, order_id, from_status, to_status, changed_by, changed_at)
+SELECT gen_random_uuid(), order_id, NULL, status, user_id, created_at
+FROM orders;
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop order_status_history table
+DROP TABLE IF EXISTS order_status_history;
+
+-- Drop status from orders table
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Create cart_items table
+CREATE TABLE cart_items (
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, product_id)
+);
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop cart_items table
+DROP TABLE IF EXISTS cart_items;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Create refresh_tokens table
+CREATE TABLE refresh_tokens (
+    token_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    family_id UUID NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    replaced_by UUID REFERENCES refresh_tokens(token_id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop refresh_tokens table
+DROP TABLE IF EXISTS refresh_tokens;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Add token_version to users table
+ALTER TABLE users ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+-- Create revoked_tokens table
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id UUID REFERENCES users(user_id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop revoked_tokens table
+DROP TABLE IF EXISTS revoked_tokens;
+
+-- Drop token_version from users table
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
+
+----------
+-- +goose StatementEnd
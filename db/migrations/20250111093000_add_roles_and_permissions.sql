@@ -0,0 +1,90 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Create roles table
+CREATE TABLE roles (
+    role_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create permissions table
+CREATE TABLE permissions (
+    permission_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create role_permissions table
+CREATE TABLE role_permissions (
+    role_id UUID NOT NULL REFERENCES roles(role_id) ON DELETE CASCADE,
+    permission_id UUID NOT NULL REFERENCES permissions(permission_id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+-- Create user_roles table
+CREATE TABLE user_roles (
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles(role_id) ON DELETE CASCADE,
+    assigned_by UUID REFERENCES users(user_id) ON DELETE SET NULL,
+    assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role_id)
+);
+
+-- Seed roles
+INSERT INTO roles (role_id, name, description, created_at)
+VALUES
+    (gen_random_uuid(), 'admin', 'Full access to the store', CURRENT_TIMESTAMP),
+    (gen_random_uuid(), 'catalog-manager', 'Manages products and the catalog', CURRENT_TIMESTAMP),
+    (gen_random_uuid(), 'support-agent', 'Looks after customer orders', CURRENT_TIMESTAMP),
+    (gen_random_uuid(), 'finance', 'Handles payments and refunds', CURRENT_TIMESTAMP);
+
+-- Seed permissions
+INSERT INTO permissions (permission_id, name, description, created_at)
+VALUES
+    (gen_random_uuid(), 'products:write', 'Create, update and delete products', CURRENT_TIMESTAMP),
+    (gen_random_uuid(), 'orders:read:any', 'Read orders of any user', CURRENT_TIMESTAMP),
+    (gen_random_uuid(), 'orders:write:any', 'Move orders of any user through fulfilment', CURRENT_TIMESTAMP),
+    (gen_random_uuid(), 'orders:refund', 'Refund orders', CURRENT_TIMESTAMP),
+    (gen_random_uuid(), 'roles:manage', 'Assign and remove roles of users', CURRENT_TIMESTAMP);
+
+-- Map roles to permissions
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id
+FROM roles r
+JOIN permissions p ON
+    r.name = 'admin'
+    OR (r.name = 'catalog-manager' AND p.name IN ('products:write'))
+    OR (r.name = 'support-agent' AND p.name IN ('orders:read:any', 'orders:write:any'))
+    OR (r.name = 'finance' AND p.name IN ('orders:read:any', 'orders:refund'));
+
+-- Carry existing users.role values over as role assignments
+INSERT INTO user_roles (user_id, role_id, assigned_at)
+SELECT u.user_id, r.role_id, CURRENT_TIMESTAMP
+FROM users u
+JOIN roles r ON r.name = u.role;
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop user_roles table
+DROP TABLE IF EXISTS user_roles;
+
+-- Drop role_permissions table
+DROP TABLE IF EXISTS role_permissions;
+
+-- Drop permissions table
+DROP TABLE IF EXISTS permissions;
+
+-- Drop roles table
+DROP TABLE IF EXISTS roles;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Index every sortable column together with the tiebreaker used by cursors
+CREATE INDEX idx_products_price ON products (price, product_id);
+CREATE INDEX idx_products_created_at ON products (created_at, product_id);
+CREATE INDEX idx_products_name ON products (name, product_id);
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop product listing indexes
+DROP INDEX IF EXISTS idx_products_name;
+DROP INDEX IF EXISTS idx_products_created_at;
+DROP INDEX IF EXISTS idx_products_price;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Add a generated full-text search column, weighting names above descriptions
+ALTER TABLE products
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+) STORED;
+
+-- Index the search column
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop product search index and column
+DROP INDEX IF EXISTS idx_products_search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Create categories table, nested through parent_id
+CREATE TABLE categories (
+    category_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    parent_id UUID REFERENCES categories(category_id) ON DELETE RESTRICT,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (parent_id <> category_id)
+);
+
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
+
+-- Create product_categories table linking products to categories
+CREATE TABLE product_categories (
+    product_id UUID NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(category_id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX idx_product_categories_category_id ON product_categories (category_id);
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop product_categories table
+DROP TABLE IF EXISTS product_categories;
+
+-- Drop categories table
+DROP TABLE IF EXISTS categories;
+
+----------
+-- +goose StatementEnd
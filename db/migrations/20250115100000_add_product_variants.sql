@@ -0,0 +1,102 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Create product_variants table, one row per sellable SKU
+CREATE TABLE product_variants (
+    variant_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    sku VARCHAR(64) NOT NULL UNIQUE,
+    options JSONB NOT NULL DEFAULT '{}',
+    price DECIMAL(10, 2) CHECK (price > 0),
+    stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_product_variants_product_id ON product_variants(product_id);
+
+-- Every product has exactly one default variant
+CREATE UNIQUE INDEX idx_product_variants_default ON product_variants(product_id) WHERE is_default;
+
+-- Migrate every existing product to a single default variant holding its stock
+INSERT INTO product_variants (variant_id, product_id, sku, options, price, stock, is_default, created_at, updated_at)
+SELECT gen_random_uuid(), product_id, 'SKU-' || UPPER(LEFT(REPLACE(product_id::text, '-', ''), 12)),
+    '{}', NULL, GREATEST(COALESCE(stock, 0), 0), TRUE, created_at, updated_at
+FROM products;
+
+-- Keep products.stock as the total stock of all variants
+CREATE OR REPLACE FUNCTION sync_product_stock() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE products
+        SET stock = (SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = OLD.product_id)
+        WHERE product_id = OLD.product_id;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        UPDATE products
+        SET stock = (SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = NEW.product_id)
+        WHERE product_id = NEW.product_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_product_variants_stock
+AFTER INSERT OR DELETE OR UPDATE OF stock, product_id ON product_variants
+FOR EACH ROW EXECUTE FUNCTION sync_product_stock();
+
+-- Order items reference the variant that was sold
+ALTER TABLE order_items
+    ADD COLUMN variant_id UUID REFERENCES product_variants(variant_id) ON DELETE SET NULL;
+
+UPDATE order_items oi
+SET variant_id = v.variant_id
+FROM product_variants v
+WHERE v.product_id = oi.product_id
+AND v.is_default;
+
+-- Cart items are keyed by variant instead of product
+ALTER TABLE cart_items
+    ADD COLUMN variant_id UUID REFERENCES product_variants(variant_id) ON DELETE CASCADE;
+
+UPDATE cart_items ci
+SET variant_id = v.variant_id
+FROM product_variants v
+WHERE v.product_id = ci.product_id
+AND v.is_default;
+
+ALTER TABLE cart_items ALTER COLUMN variant_id SET NOT NULL;
+ALTER TABLE cart_items DROP CONSTRAINT cart_items_pkey;
+ALTER TABLE cart_items ADD PRIMARY KEY (user_id, variant_id);
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Fold cart items back to one row per product
+DELETE FROM cart_items ci
+USING product_variants v
+WHERE v.variant_id = ci.variant_id
+AND NOT v.is_default;
+
+ALTER TABLE cart_items DROP CONSTRAINT cart_items_pkey;
+ALTER TABLE cart_items ADD PRIMARY KEY (user_id, product_id);
+ALTER TABLE cart_items DROP COLUMN IF EXISTS variant_id;
+
+-- Drop variant from order_items table
+ALTER TABLE order_items DROP COLUMN IF EXISTS variant_id;
+
+-- Drop stock sync trigger
+DROP TRIGGER IF EXISTS trg_product_variants_stock ON product_variants;
+DROP FUNCTION IF EXISTS sync_product_stock();
+
+-- Drop product_variants table
+DROP TABLE IF EXISTS product_variants;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Create product_images table, ordered per product by position
+CREATE TABLE product_images (
+    image_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL UNIQUE,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    position INT NOT NULL CHECK (position > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, position)
+);
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop product_images table
+DROP TABLE IF EXISTS product_images;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Create product_image_thumbnails table, one row per generated size of an image
+CREATE TABLE product_image_thumbnails (
+    image_id UUID NOT NULL REFERENCES product_images(image_id) ON DELETE CASCADE,
+    size INT NOT NULL CHECK (size > 0),
+    storage_key TEXT NOT NULL UNIQUE,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (image_id, size)
+);
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop product_image_thumbnails table
+DROP TABLE IF EXISTS product_image_thumbnails;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Add external_id to products, the stable key bulk imports upsert by
+ALTER TABLE products ADD COLUMN external_id VARCHAR(255) UNIQUE;
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop external_id from products
+ALTER TABLE products DROP COLUMN IF EXISTS external_id;
+
+----------
+-- +goose StatementEnd
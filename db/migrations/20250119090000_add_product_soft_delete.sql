@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Add deleted_at to products, set when a product is archived
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMP;
+
+-- Products are archived rather than deleted, so never let a delete take order lines with it
+ALTER TABLE order_items DROP CONSTRAINT order_items_product_id_fkey;
+ALTER TABLE order_items
+    ADD CONSTRAINT order_items_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE RESTRICT;
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Restore the cascading foreign key on order_items
+ALTER TABLE order_items DROP CONSTRAINT order_items_product_id_fkey;
+ALTER TABLE order_items
+    ADD CONSTRAINT order_items_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE;
+
+-- Drop deleted_at from products
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Add version to products, served as the ETag for optimistic concurrency control
+ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- Bump the version on every change to a product row, including stock synced from its variants
+CREATE OR REPLACE FUNCTION bump_product_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_products_version
+BEFORE UPDATE ON products
+FOR EACH ROW EXECUTE FUNCTION bump_product_version();
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop the version trigger
+DROP TRIGGER IF EXISTS trg_products_version ON products;
+DROP FUNCTION IF EXISTS bump_product_version();
+
+-- Drop version from products
+ALTER TABLE products DROP COLUMN IF EXISTS version;
+
+----------
+-- +goose StatementEnd
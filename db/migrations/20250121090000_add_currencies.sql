@@ -0,0 +1,61 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Add currency to products, the ISO 4217 code their prices are in
+ALTER TABLE products ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Create exchange_rates table, where 1 unit of base_currency buys rate units of quote_currency
+CREATE TABLE exchange_rates (
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate DECIMAL(18, 8) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_currency, quote_currency),
+    CHECK (base_currency <> quote_currency)
+);
+
+-- Orders are charged in one currency, chosen at checkout
+ALTER TABLE orders ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Order items snapshot the product currency and the rate used to convert from it
+ALTER TABLE order_items
+    ADD COLUMN product_currency CHAR(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN exchange_rate DECIMAL(18, 8) NOT NULL DEFAULT 1;
+
+-- Seed the permission to maintain pricing data such as exchange rates
+INSERT INTO permissions (permission_id, name, description, created_at)
+VALUES (gen_random_uuid(), 'pricing:manage', 'Maintain exchange rates and other pricing data', CURRENT_TIMESTAMP);
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id
+FROM roles r
+JOIN permissions p ON p.name = 'pricing:manage'
+WHERE r.name IN ('admin', 'finance');
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop the pricing permission, with its role mappings
+DELETE FROM permissions WHERE name = 'pricing:manage';
+
+-- Drop the currency snapshot from order_items
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS product_currency;
+
+-- Drop currency from orders
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+
+-- Drop exchange_rates table
+DROP TABLE IF EXISTS exchange_rates;
+
+-- Drop currency from products
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
+
+----------
+-- +goose StatementEnd
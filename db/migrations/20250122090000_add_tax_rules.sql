@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- Add tax_class to products, picking which tax rules apply to them
+ALTER TABLE products ADD COLUMN tax_class VARCHAR(50) NOT NULL DEFAULT 'standard';
+
+-- Create tax_rules table. Rows are never changed once written: a new version of a rule
+-- supersedes the old one, so orders that reference an old version can be recomputed exactly
+CREATE TABLE tax_rules (
+    tax_rule_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    country CHAR(2) NOT NULL,
+    -- Empty region means the rule applies to the whole country
+    region VARCHAR(50) NOT NULL DEFAULT '',
+    tax_class VARCHAR(50) NOT NULL DEFAULT 'standard',
+    rate DECIMAL(18, 8) NOT NULL CHECK (rate >= 0 AND rate <= 1),
+    inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    version INTEGER NOT NULL,
+    created_by UUID REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    superseded_at TIMESTAMP,
+    UNIQUE (country, region, tax_class, version)
+);
+
+-- At most one version of a rule is in force at a time
+CREATE UNIQUE INDEX idx_tax_rules_active ON tax_rules (country, region, tax_class) WHERE superseded_at IS NULL;
+
+-- Orders are taxed by the country and region they are placed for
+ALTER TABLE orders
+    ADD COLUMN tax_country CHAR(2),
+    ADD COLUMN tax_region VARCHAR(50) NOT NULL DEFAULT '';
+
+-- Order items snapshot the tax rule version they were taxed with and the tax it produced
+ALTER TABLE order_items
+    ADD COLUMN tax_rule_id UUID REFERENCES tax_rules(tax_rule_id),
+    ADD COLUMN tax_rate DECIMAL(18, 8) NOT NULL DEFAULT 0,
+    ADD COLUMN tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN tax_price DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop the tax snapshot from order_items
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS tax_price,
+    DROP COLUMN IF EXISTS tax_inclusive,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_rule_id;
+
+-- Drop the tax location from orders
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS tax_region,
+    DROP COLUMN IF EXISTS tax_country;
+
+-- Drop tax_rules table
+DROP TABLE IF EXISTS tax_rules;
+
+-- Drop tax_class from products
+ALTER TABLE products DROP COLUMN IF EXISTS tax_class;
+
+----------
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+----------
+
+-- The product ETag covers its variants and images too, so changes to them bump products.version.
+-- Touching the product row is enough: trg_products_version does the bump.
+CREATE OR REPLACE FUNCTION touch_product() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE products SET updated_at = CURRENT_TIMESTAMP WHERE product_id = OLD.product_id;
+    END IF;
+    IF TG_OP <> 'DELETE' AND (TG_OP = 'INSERT' OR NEW.product_id IS DISTINCT FROM OLD.product_id) THEN
+        UPDATE products SET updated_at = CURRENT_TIMESTAMP WHERE product_id = NEW.product_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Variant inserts, deletes and stock changes already touch the product through sync_product_stock
+CREATE TRIGGER trg_product_variants_touch_product
+AFTER UPDATE OF sku, options, price, is_default ON product_variants
+FOR EACH ROW EXECUTE FUNCTION touch_product();
+
+CREATE TRIGGER trg_product_images_touch_product
+AFTER INSERT OR DELETE OR UPDATE ON product_images
+FOR EACH ROW EXECUTE FUNCTION touch_product();
+
+-- Thumbnails reach their product through the image they belong to
+CREATE OR REPLACE FUNCTION touch_product_of_image() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE products
+    SET updated_at = CURRENT_TIMESTAMP
+    WHERE product_id = (
+        SELECT product_id FROM product_images
+        WHERE image_id = CASE WHEN TG_OP = 'DELETE' THEN OLD.image_id ELSE NEW.image_id END
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_product_image_thumbnails_touch_product
+AFTER INSERT OR DELETE OR UPDATE ON product_image_thumbnails
+FOR EACH ROW EXECUTE FUNCTION touch_product_of_image();
+
+----------
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+----------
+
+-- Drop the triggers that bump the product version on related changes
+DROP TRIGGER IF EXISTS trg_product_image_thumbnails_touch_product ON product_image_thumbnails;
+DROP FUNCTION IF EXISTS touch_product_of_image();
+DROP TRIGGER IF EXISTS trg_product_images_touch_product ON product_images;
+DROP TRIGGER IF EXISTS trg_product_variants_touch_product ON product_variants;
+DROP FUNCTION IF EXISTS touch_product();
+
+----------
+-- +goose StatementEnd
@@ -6,16 +6,24 @@ import (
 )
 
 type EnvConfig struct {
-	DATABASE_URL string
-	SERVER_PORT  string
-	JWT_SECRET   string
+	DATABASE_URL    string
+	SERVER_PORT     string
+	JWT_KEYS_DIR    string
+	JWT_SIGNING_KID string
+	STORAGE_DIR     string
+	STORAGE_URL     string
+	THUMBNAIL_SIZES string
 }
 
 func LoadEnvConfig() *EnvConfig {
 	return &EnvConfig{
-		DATABASE_URL: MustGetEnv("DATABASE_URL"),
-		SERVER_PORT:  MustGetEnv("SERVER_PORT"),
-		JWT_SECRET:   MustGetEnv("JWT_SECRET"),
+		DATABASE_URL:    MustGetEnv("DATABASE_URL"),
+		SERVER_PORT:     MustGetEnv("SERVER_PORT"),
+		JWT_KEYS_DIR:    MustGetEnv("JWT_KEYS_DIR"),
+		JWT_SIGNING_KID: MustGetEnv("JWT_SIGNING_KID"),
+		STORAGE_DIR:     MustGetEnv("STORAGE_DIR"),
+		STORAGE_URL:     MustGetEnv("STORAGE_URL"),
+		THUMBNAIL_SIZES: MustGetEnv("THUMBNAIL_SIZES"),
 	}
 }
 
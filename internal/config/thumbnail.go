@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ThumbnailConfig struct {
+	// Longest edge of each generated thumbnail, in pixels
+	Sizes     []int
+	Workers   int
+	QueueSize int
+	// Images above this many pixels are not decoded
+	MaxPixels int
+}
+
+// NewThumbnailConfig parses a comma separated list of sizes such as "150,400,1200"
+func NewThumbnailConfig(sizes string) (ThumbnailConfig, error) {
+	config := ThumbnailConfig{
+		Workers:   2,
+		QueueSize: 100,
+		MaxPixels: 50_000_000,
+	}
+
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(sizes, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		size, err := strconv.Atoi(part)
+		if err != nil || size <= 0 || size > 4096 {
+			return ThumbnailConfig{}, fmt.Errorf("invalid thumbnail size %q: must be between 1 and 4096", part)
+		}
+		if !seen[size] {
+			seen[size] = true
+			config.Sizes = append(config.Sizes, size)
+		}
+	}
+	sort.Ints(config.Sizes)
+
+	return config, nil
+}
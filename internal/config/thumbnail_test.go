@@ -0,0 +1,92 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/officiallysidsingh/ecom-server/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewThumbnailConfig(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name        string
+		sizes       string
+		expectErr   bool
+		expectSizes []int
+	}{
+		{
+			name:        "Sizes are sorted",
+			sizes:       "1200,150,400",
+			expectSizes: []int{150, 400, 1200},
+		},
+		{
+			name:        "Whitespace and empty entries are ignored",
+			sizes:       " 150 , ,400,",
+			expectSizes: []int{150, 400},
+		},
+		{
+			name:        "Duplicate sizes are kept once",
+			sizes:       "400,150,400,150",
+			expectSizes: []int{150, 400},
+		},
+		{
+			name:        "Bounds are inclusive",
+			sizes:       "1,4096",
+			expectSizes: []int{1, 4096},
+		},
+		{
+			name:        "Empty list disables thumbnails",
+			sizes:       "",
+			expectSizes: nil,
+		},
+		{
+			name:      "Not a number",
+			sizes:     "150,large",
+			expectErr: true,
+		},
+		{
+			name:      "Decimal size",
+			sizes:     "150.5",
+			expectErr: true,
+		},
+		{
+			name:      "Zero size",
+			sizes:     "0,150",
+			expectErr: true,
+		},
+		{
+			name:      "Negative size",
+			sizes:     "-150",
+			expectErr: true,
+		},
+		{
+			name:      "Size above the limit",
+			sizes:     "150,4097",
+			expectErr: true,
+		},
+		{
+			name:      "Space separated list",
+			sizes:     "150 400",
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.NewThumbnailConfig(tt.sizes)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Equal(t, config.ThumbnailConfig{}, cfg)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectSizes, cfg.Sizes)
+				assert.Positive(t, cfg.Workers)
+				assert.Positive(t, cfg.QueueSize)
+				assert.Positive(t, cfg.MaxPixels)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/services"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+type CartHandler struct {
+	service services.CartService
+}
+
+func NewCartHandler(service services.CartService) *CartHandler {
+	return &CartHandler{
+		service: service,
+	}
+}
+
+func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	// Optional currency to price the cart in
+	currency := r.URL.Query().Get("currency")
+
+	cart, err := h.service.Get(r.Context(), currency)
+	if err != nil {
+		log.Printf("Error fetching cart: %v", err.Error())
+		switch {
+		case errors.Is(err, services.ErrInvalidCurrency),
+			errors.Is(err, services.ErrExchangeRateNotFound):
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) AddCartItem(w http.ResponseWriter, r *http.Request) {
+	var itemReq models.AddCartItemRequest
+
+	// Decode Cart Item from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &itemReq)
+	if err != nil {
+		log.Printf("Error decoding cart item data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	if err := h.service.AddItem(r.Context(), &itemReq); err != nil {
+		log.Printf("Error adding cart item: %v", err.Error())
+		switch {
+		case errors.Is(err, services.ErrInvalidQuantity):
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrProductNotFound),
+			errors.Is(err, services.ErrVariantNotFound):
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Product with id: %s added to cart successfully", itemReq.ProductID)
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": res})
+}
+
+func (h *CartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	var itemReq models.UpdateCartItemRequest
+
+	// Get VariantID from URL
+	variantID := chi.URLParam(r, "variantID")
+
+	// Decode Cart Item from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &itemReq)
+	if err != nil {
+		log.Printf("Error decoding cart item data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	if err := h.service.UpdateItem(r.Context(), variantID, itemReq.Quantity); err != nil {
+		log.Printf("Error updating cart item (Variant ID: %s): %v", variantID, err.Error())
+		switch {
+		case errors.Is(err, services.ErrInvalidQuantity):
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrCartItemNotFound):
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Cart item with variant id: %s updated successfully", variantID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func (h *CartHandler) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
+	// Get VariantID from URL
+	variantID := chi.URLParam(r, "variantID")
+
+	if err := h.service.RemoveItem(r.Context(), variantID); err != nil {
+		log.Printf("Error removing cart item (Variant ID: %s): %v", variantID, err.Error())
+		if errors.Is(err, services.ErrCartItemNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Cart item with variant id: %s removed successfully", variantID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func (h *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Clear(r.Context()); err != nil {
+		log.Printf("Error clearing cart: %v", err.Error())
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Returning successful response
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Cart cleared successfully"})
+}
+
+func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	var checkoutReq models.CheckoutRequest
+
+	// Decode Checkout from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &checkoutReq)
+	if err != nil {
+		log.Printf("Error decoding checkout data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	// Country is required to tax the order; a request without one gets a 400 naming the field
+	orderID, err := h.service.Checkout(r.Context(), &checkoutReq)
+	if err != nil {
+		log.Printf("Error checking out cart: %v", err.Error())
+		switch {
+		case errors.Is(err, services.ErrInsufficientStock),
+			errors.Is(err, services.ErrCartChanged):
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, services.ErrEmptyCart),
+			errors.Is(err, services.ErrMissingPaymentMethod),
+			errors.Is(err, services.ErrMissingCountry),
+			errors.Is(err, services.ErrInvalidCurrency),
+			errors.Is(err, services.ErrExchangeRateNotFound),
+			errors.Is(err, services.ErrInvalidCountry),
+			errors.Is(err, services.ErrInvalidRegion),
+			errors.Is(err, services.ErrProductNotFound),
+			errors.Is(err, services.ErrVariantNotFound):
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Order with id: %s placed successfully", orderID)
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": res, "order_id": orderID})
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/services"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+type CategoryHandler struct {
+	service services.CategoryService
+}
+
+func NewCategoryHandler(service services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+	}
+}
+
+func (h *CategoryHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.GetTree(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, tree)
+}
+
+func (h *CategoryHandler) GetCategoryById(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "id")
+
+	category, err := h.service.GetByID(r.Context(), categoryID)
+	if err != nil {
+		respondWithCategoryError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, category)
+}
+
+func (h *CategoryHandler) GetCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "id")
+
+	// Parse paging, sorting and filters from the query string
+	query, err := parseProductQuery(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.GetProducts(r.Context(), categoryID, query)
+	if err != nil {
+		respondWithCategoryError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, page)
+}
+
+func (h *CategoryHandler) AddCategory(w http.ResponseWriter, r *http.Request) {
+	var categoryReq models.CategoryRequest
+
+	// Decode Category from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &categoryReq)
+	if err != nil {
+		log.Printf("Error decoding category data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	categoryID, err := h.service.Create(r.Context(), &categoryReq)
+	if err != nil {
+		log.Printf("Error adding category: %v", err.Error())
+		respondWithCategoryError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Category with id: %s added successfully", categoryID)
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": res})
+}
+
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var categoryReq models.CategoryRequest
+
+	// Get CategoryID from URL
+	categoryID := chi.URLParam(r, "id")
+
+	// Decode Category from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &categoryReq)
+	if err != nil {
+		log.Printf("Error decoding category data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	if err := h.service.Update(r.Context(), &categoryReq, categoryID); err != nil {
+		log.Printf("Error updating category (ID: %s): %v", categoryID, err.Error())
+		respondWithCategoryError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Category with id: %s updated successfully", categoryID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	// Get CategoryID from URL
+	categoryID := chi.URLParam(r, "id")
+
+	if err := h.service.Delete(r.Context(), categoryID); err != nil {
+		log.Printf("Error deleting category (ID: %s): %v", categoryID, err.Error())
+		respondWithCategoryError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Category with id: %s deleted successfully", categoryID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func (h *CategoryHandler) AddCategoryProduct(w http.ResponseWriter, r *http.Request) {
+	// Get CategoryID and ProductID from URL
+	categoryID := chi.URLParam(r, "id")
+	productID := chi.URLParam(r, "productID")
+
+	if err := h.service.AddProduct(r.Context(), categoryID, productID); err != nil {
+		log.Printf("Error adding product (ID: %s) to category (ID: %s): %v", productID, categoryID, err.Error())
+		respondWithCategoryError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Product with id: %s added to category with id: %s successfully", productID, categoryID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func (h *CategoryHandler) RemoveCategoryProduct(w http.ResponseWriter, r *http.Request) {
+	// Get CategoryID and ProductID from URL
+	categoryID := chi.URLParam(r, "id")
+	productID := chi.URLParam(r, "productID")
+
+	if err := h.service.RemoveProduct(r.Context(), categoryID, productID); err != nil {
+		log.Printf("Error removing product (ID: %s) from category (ID: %s): %v", productID, categoryID, err.Error())
+		respondWithCategoryError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Product with id: %s removed from category with id: %s successfully", productID, categoryID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+// Map category errors to status codes
+func respondWithCategoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrMissingCategoryName),
+		errors.Is(err, services.ErrParentCategoryNotFound),
+		errors.Is(err, services.ErrInvalidProductQuery),
+		errors.Is(err, services.ErrInvalidCursor),
+		errors.Is(err, services.ErrInvalidCurrency),
+		errors.Is(err, services.ErrExchangeRateNotFound):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrCategoryNotFound),
+		errors.Is(err, services.ErrProductNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrCategoryCycle),
+		errors.Is(err, services.ErrCategoryHasChildren):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
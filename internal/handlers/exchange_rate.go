@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/services"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+type ExchangeRateHandler struct {
+	service services.ExchangeRateService
+}
+
+func NewExchangeRateHandler(service services.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		service: service,
+	}
+}
+
+func (h *ExchangeRateHandler) GetAllExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.service.GetAll(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, rates)
+}
+
+func (h *ExchangeRateHandler) SetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	var rateReq models.ExchangeRateRequest
+
+	// Get the currency pair from URL
+	base := chi.URLParam(r, "base")
+	quote := chi.URLParam(r, "quote")
+
+	// Decode Rate from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &rateReq)
+	if err != nil {
+		log.Printf("Error decoding exchange rate data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	rate, err := h.service.Set(r.Context(), base, quote, &rateReq)
+	if err != nil {
+		log.Printf("Error setting exchange rate from %s to %s: %v", base, quote, err.Error())
+		respondWithExchangeRateError(w, err)
+		return
+	}
+
+	// Returning successful response
+	utils.RespondWithJSON(w, http.StatusOK, rate)
+}
+
+func (h *ExchangeRateHandler) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	// Get the currency pair from URL
+	base := chi.URLParam(r, "base")
+	quote := chi.URLParam(r, "quote")
+
+	if err := h.service.Delete(r.Context(), base, quote); err != nil {
+		log.Printf("Error deleting exchange rate from %s to %s: %v", base, quote, err.Error())
+		respondWithExchangeRateError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Exchange rate from %s to %s deleted successfully", base, quote)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func respondWithExchangeRateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCurrency),
+		errors.Is(err, services.ErrInvalidRate):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrExchangeRateNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
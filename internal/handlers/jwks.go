@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+type JWKSHandler struct {
+	keys *utils.KeySet
+}
+
+func NewJWKSHandler(keys *utils.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	// Let verifiers cache the keys, but pick up a rotation within minutes
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	utils.RespondWithJSON(w, http.StatusOK, h.keys.JWKS())
+}
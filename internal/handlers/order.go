@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
 	"github.com/officiallysidsingh/ecom-server/internal/services"
 	"github.com/officiallysidsingh/ecom-server/internal/utils"
 )
@@ -39,3 +43,105 @@ func (h *OrderHandler) GetOrderById(w http.ResponseWriter, r *http.Request) {
 
 	utils.RespondWithJSON(w, http.StatusOK, order)
 }
+
+func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var orderReq models.CreateOrderRequest
+
+	// Decode Order from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &orderReq)
+	if err != nil {
+		log.Printf("Error decoding order data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	// Country is required to tax the order; a request without one gets a 400 naming the field
+	orderID, err := h.service.Create(r.Context(), &orderReq)
+	if err != nil {
+		log.Printf("Error creating order: %v", err.Error())
+		switch {
+		case errors.Is(err, services.ErrInsufficientStock):
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, services.ErrMissingPaymentMethod),
+			errors.Is(err, services.ErrMissingCountry),
+			errors.Is(err, services.ErrEmptyOrder),
+			errors.Is(err, services.ErrInvalidQuantity),
+			errors.Is(err, services.ErrInvalidCurrency),
+			errors.Is(err, services.ErrExchangeRateNotFound),
+			errors.Is(err, services.ErrInvalidCountry),
+			errors.Is(err, services.ErrInvalidRegion),
+			errors.Is(err, services.ErrProductNotFound),
+			errors.Is(err, services.ErrVariantNotFound):
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Order with id: %s placed successfully", orderID)
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": res, "order_id": orderID})
+}
+
+// TransitionOrder returns a handler that moves an order to the given status
+func (h *OrderHandler) TransitionOrder(to models.OrderStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get OrderID from URL
+		orderID := chi.URLParam(r, "id")
+
+		if err := h.service.Transition(r.Context(), orderID, to); err != nil {
+			log.Printf("Error moving order (ID: %s) to %s: %v", orderID, to, err.Error())
+			switch {
+			case errors.Is(err, services.ErrOrderNotFound):
+				utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			case errors.Is(err, services.ErrInvalidTransition),
+				errors.Is(err, services.ErrStatusConflict):
+				utils.RespondWithError(w, http.StatusConflict, err.Error())
+			default:
+				utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		// Returning successful response
+		res := fmt.Sprintf("Order with id: %s is now %s", orderID, to)
+		utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+	}
+}
+
+func (h *OrderHandler) GetOrderStatusHistory(w http.ResponseWriter, r *http.Request) {
+	// Get OrderID from URL
+	orderID := chi.URLParam(r, "id")
+
+	history, err := h.service.GetStatusHistory(r.Context(), orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, history)
+}
+
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	// Get OrderID from URL
+	orderID := chi.URLParam(r, "id")
+
+	if err := h.service.Cancel(r.Context(), orderID); err != nil {
+		log.Printf("Error cancelling order (ID: %s): %v", orderID, err.Error())
+		switch {
+		case errors.Is(err, services.ErrOrderNotFound):
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrInvalidTransition),
+			errors.Is(err, services.ErrStatusConflict):
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Order with id: %s cancelled successfully", orderID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
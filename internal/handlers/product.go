@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/officiallysidsingh/ecom-server/internal/models"
@@ -22,24 +27,86 @@ func NewProductHandler(service services.ProductService) *ProductHandler {
 }
 
 func (h *ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := h.service.GetAll(r.Context())
+	// Parse paging, sorting and filters from the query string
+	query, err := parseProductQuery(r)
 	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.GetAll(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidProductQuery) ||
+			errors.Is(err, services.ErrInvalidCursor) ||
+			errors.Is(err, services.ErrInvalidCurrency) ||
+			errors.Is(err, services.ErrExchangeRateNotFound) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, products)
+	utils.RespondWithJSON(w, http.StatusOK, page)
+}
+
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	// Parse paging and filters from the query string
+	query, err := parseProductQuery(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.Search = r.URL.Query().Get("q")
+
+	page, err := h.service.Search(r.Context(), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMissingSearchQuery),
+			errors.Is(err, services.ErrInvalidProductQuery),
+			errors.Is(err, services.ErrInvalidCursor),
+			errors.Is(err, services.ErrInvalidCurrency),
+			errors.Is(err, services.ErrExchangeRateNotFound):
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, page)
 }
 
 func (h *ProductHandler) GetProductById(w http.ResponseWriter, r *http.Request) {
 	productID := chi.URLParam(r, "id")
 
-	product, err := h.service.GetByID(r.Context(), productID)
+	currency := r.URL.Query().Get("currency")
+
+	product, err := h.service.GetByID(r.Context(), productID, currency)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCurrency) || errors.Is(err, services.ErrExchangeRateNotFound) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.RespondWithError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
+	// Converted prices move with the exchange rates, so only the stored product carries an ETag
+	if currency != "" {
+		utils.RespondWithJSON(w, http.StatusOK, product)
+		return
+	}
+
+	// The version is the ETag clients send back in If-Match when writing. Variant and image
+	// changes bump it too (see the touch_product triggers), so it covers the whole response
+	etag := utils.FormatVersionETag(product.Version)
+	w.Header().Set("ETag", etag)
+	if utils.IfNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	utils.RespondWithJSON(w, http.StatusOK, product)
 }
 
@@ -57,7 +124,7 @@ func (h *ProductHandler) AddProduct(w http.ResponseWriter, r *http.Request) {
 	productID, err := h.service.Create(r.Context(), &product)
 	if err != nil {
 		log.Printf("Error adding product: %v", err.Error())
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithProductWriteError(w, err)
 		return
 	}
 
@@ -72,6 +139,12 @@ func (h *ProductHandler) PutUpdateProduct(w http.ResponseWriter, r *http.Request
 	// Get ProductID from URL
 	productID := chi.URLParam(r, "id")
 
+	// Writes must name the version they were based on
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	// Decode Product from Request Body to Struct
 	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &product)
 	if err != nil {
@@ -81,25 +154,32 @@ func (h *ProductHandler) PutUpdateProduct(w http.ResponseWriter, r *http.Request
 	}
 
 	// Call the function to add the product in DB
-	if err := h.service.PutUpdate(r.Context(), &product, productID); err != nil {
+	if err := h.service.PutUpdate(r.Context(), &product, productID, version); err != nil {
 		log.Printf("Error updating product (ID: %s): %v", productID, err.Error())
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithProductWriteError(w, err)
 		return
 	}
 
-	// Returning successful response
+	// Returning successful response with the new version
+	w.Header().Set("ETag", utils.FormatVersionETag(product.Version))
 	res := fmt.Sprintf("Product with id: %s updated successfully", productID)
 	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
 }
 
 func (h *ProductHandler) PatchUpdateProduct(w http.ResponseWriter, r *http.Request) {
-	var product models.Product
+	var patch models.ProductPatch
 
 	// Get ProductID from URL
 	productID := chi.URLParam(r, "id")
 
-	// Decode Product from JSON to Struct
-	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &product)
+	// Writes must name the version they were based on
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode the patch from JSON; fields left out of the body stay unchanged
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &patch)
 	if err != nil {
 		log.Printf("Error decoding product data: %v", err)
 		utils.RespondWithError(w, statusCode, errMessage)
@@ -107,13 +187,15 @@ func (h *ProductHandler) PatchUpdateProduct(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Call the function to update the product in DB
-	if err := h.service.PatchUpdate(r.Context(), &product, productID); err != nil {
+	newVersion, err := h.service.PatchUpdate(r.Context(), &patch, productID, version)
+	if err != nil {
 		log.Printf("Error updating product (ID: %s): %v", productID, err.Error())
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithProductWriteError(w, err)
 		return
 	}
 
-	// Returning successful response
+	// Returning successful response with the new version
+	w.Header().Set("ETag", utils.FormatVersionETag(newVersion))
 	res := fmt.Sprintf("Product with id: %s updated successfully", productID)
 	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
 }
@@ -122,10 +204,16 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	// Get ProudctID from URL
 	productID := chi.URLParam(r, "id")
 
-	// Call the function to delete the product in DB
-	if err := h.service.Delete(r.Context(), productID); err != nil {
+	// Writes must name the version they were based on
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	// Call the function to archive the product in DB
+	if err := h.service.Delete(r.Context(), productID, version); err != nil {
 		log.Printf("Error updating product (ID: %s): %v", productID, err.Error())
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithProductWriteError(w, err)
 		return
 	}
 
@@ -133,3 +221,345 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	res := fmt.Sprintf("Product with id: %s deleted successfully", productID)
 	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
 }
+
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	// Get ProductID from URL
+	productID := chi.URLParam(r, "id")
+
+	if err := h.service.Restore(r.Context(), productID); err != nil {
+		log.Printf("Error restoring product (ID: %s): %v", productID, err.Error())
+		if errors.Is(err, services.ErrProductNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Product with id: %s restored successfully", productID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func (h *ProductHandler) AddProductVariant(w http.ResponseWriter, r *http.Request) {
+	var variantReq models.VariantRequest
+
+	// Get ProductID from URL
+	productID := chi.URLParam(r, "id")
+
+	// Decode Variant from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &variantReq)
+	if err != nil {
+		log.Printf("Error decoding variant data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	variantID, err := h.service.CreateVariant(r.Context(), productID, &variantReq)
+	if err != nil {
+		log.Printf("Error adding variant to product (ID: %s): %v", productID, err.Error())
+		respondWithVariantError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Variant with id: %s added successfully", variantID)
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": res})
+}
+
+func (h *ProductHandler) UpdateProductVariant(w http.ResponseWriter, r *http.Request) {
+	var variantReq models.VariantRequest
+
+	// Get ProductID and VariantID from URL
+	productID := chi.URLParam(r, "id")
+	variantID := chi.URLParam(r, "variantID")
+
+	// Decode Variant from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &variantReq)
+	if err != nil {
+		log.Printf("Error decoding variant data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	if err := h.service.UpdateVariant(r.Context(), productID, variantID, &variantReq); err != nil {
+		log.Printf("Error updating variant (ID: %s): %v", variantID, err.Error())
+		respondWithVariantError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Variant with id: %s updated successfully", variantID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func (h *ProductHandler) DeleteProductVariant(w http.ResponseWriter, r *http.Request) {
+	// Get ProductID and VariantID from URL
+	productID := chi.URLParam(r, "id")
+	variantID := chi.URLParam(r, "variantID")
+
+	if err := h.service.DeleteVariant(r.Context(), productID, variantID); err != nil {
+		log.Printf("Error deleting variant (ID: %s): %v", variantID, err.Error())
+		respondWithVariantError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Variant with id: %s deleted successfully", variantID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func (h *ProductHandler) UploadProductImage(w http.ResponseWriter, r *http.Request) {
+	// Get ProductID from URL
+	productID := chi.URLParam(r, "id")
+
+	// Limit request body size, leaving room for the multipart framing
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxImageSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		log.Printf("Error parsing image upload: %v", err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, services.ErrImageTooLarge.Error())
+			return
+		}
+		utils.RespondWithError(w, http.StatusBadRequest, "Request must be multipart/form-data")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		log.Printf("Error reading image upload: %v", err)
+		utils.RespondWithError(w, http.StatusBadRequest, "Form field image is required")
+		return
+	}
+	defer file.Close()
+
+	image, err := h.service.UploadImage(r.Context(), productID, file, header.Size)
+	if err != nil {
+		log.Printf("Error uploading image for product (ID: %s): %v", productID, err.Error())
+		switch {
+		case errors.Is(err, services.ErrImageTooLarge):
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, services.ErrUnsupportedImageType):
+			utils.RespondWithError(w, http.StatusUnsupportedMediaType, err.Error())
+		case errors.Is(err, services.ErrProductNotFound):
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Returning successful response
+	utils.RespondWithJSON(w, http.StatusCreated, image)
+}
+
+func (h *ProductHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	// Pick the format from the content type
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var format string
+	switch mediaType {
+	case "text/csv":
+		format = models.ImportFormatCSV
+	case "application/x-ndjson", "application/jsonl":
+		format = models.ImportFormatJSONL
+	default:
+		utils.RespondWithError(w, http.StatusUnsupportedMediaType, "Content-Type must be text/csv or application/x-ndjson")
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+		dryRun = parsed
+	}
+
+	// Limit request body size
+	const maxImportSize = 50 * 1024 * 1024 // 50MB
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	// Large files take longer to upload than the server read timeout
+	const importReadTimeout = 5 * time.Minute // 50MB at about 170KB/s
+	if err := http.NewResponseController(w).SetReadDeadline(time.Now().Add(importReadTimeout)); err != nil {
+		log.Printf("Error extending read deadline for import: %v", err)
+	}
+
+	report, err := h.service.Import(r.Context(), format, r.Body, dryRun)
+	if err != nil {
+		log.Printf("Error importing products: %v", err.Error())
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, "Import file is too large")
+		case errors.Is(err, services.ErrInvalidImport):
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, report)
+}
+
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	// Parse sorting and filters from the query string
+	query, err := parseProductQuery(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writer, contentType, ext, err := newProductWriter(r.URL.Query().Get("format"), w)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Large catalogs take longer than the server write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Error lifting write deadline for export: %v", err)
+	}
+
+	// Headers go out with the first row, so errors before it still get a proper status
+	started := false
+	start := func() {
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, ext))
+		w.Header().Set("Trailer", exportStatusTrailer)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	err = h.service.Export(r.Context(), query, func(product *models.Product) error {
+		if !started {
+			start()
+		}
+		return writer.Write(product)
+	})
+	if err != nil {
+		log.Printf("Error exporting products: %v", err.Error())
+		if started {
+			// Too late for an error status, so cut the connection and let the client see a failed transfer
+			panic(http.ErrAbortHandler)
+		}
+		if errors.Is(err, services.ErrInvalidProductQuery) ||
+			errors.Is(err, services.ErrInvalidCurrency) ||
+			errors.Is(err, services.ErrExchangeRateNotFound) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !started {
+		start()
+	}
+	if err := writer.Close(); err != nil {
+		log.Printf("Error finishing product export: %v", err)
+		panic(http.ErrAbortHandler)
+	}
+	w.Header().Set(exportStatusTrailer, exportStatusComplete)
+}
+
+// Map variant errors to status codes
+func respondWithVariantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrMissingSKU),
+		errors.Is(err, services.ErrInvalidPrice),
+		errors.Is(err, services.ErrInvalidStock):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrProductNotFound),
+		errors.Is(err, services.ErrVariantNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrSKUExists),
+		errors.Is(err, services.ErrDefaultVariantFixed):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func respondWithProductWriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPrice),
+		errors.Is(err, services.ErrInvalidStock),
+		errors.Is(err, services.ErrMissingProductName),
+		errors.Is(err, services.ErrInvalidProductPatch),
+		errors.Is(err, services.ErrInvalidCurrency),
+		errors.Is(err, services.ErrInvalidTaxClass):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrProductNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrProductVersionMismatch):
+		utils.RespondWithError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, services.ErrStockSetOnVariants):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// Read the product version from If-Match, responding with 428 when it is missing and 412 when it is unusable
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, err := utils.ParseIfMatch(r)
+	if err != nil {
+		if errors.Is(err, utils.ErrMissingIfMatch) {
+			utils.RespondWithError(w, http.StatusPreconditionRequired, err.Error())
+			return 0, false
+		}
+		utils.RespondWithError(w, http.StatusPreconditionFailed, err.Error())
+		return 0, false
+	}
+	return version, true
+}
+
+func parseProductQuery(r *http.Request) (models.ProductQuery, error) {
+	values := r.URL.Query()
+	query := models.ProductQuery{
+		Cursor: values.Get("cursor"),
+		Sort:   values.Get("sort"),
+		Order:  strings.ToLower(values.Get("order")),
+		// Prices are converted into this currency when set
+		Currency: values.Get("currency"),
+	}
+
+	var err error
+	if v := values.Get("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil {
+			return query, fmt.Errorf("invalid limit: %q", v)
+		}
+	}
+	if v := values.Get("offset"); v != "" {
+		if query.Offset, err = strconv.Atoi(v); err != nil {
+			return query, fmt.Errorf("invalid offset: %q", v)
+		}
+	}
+	if v := values.Get("min_price"); v != "" {
+		minPrice, err := models.ParseMoney(v)
+		if err != nil {
+			return query, fmt.Errorf("invalid min_price: %q", v)
+		}
+		query.MinPrice = &minPrice
+	}
+	if v := values.Get("max_price"); v != "" {
+		maxPrice, err := models.ParseMoney(v)
+		if err != nil {
+			return query, fmt.Errorf("invalid max_price: %q", v)
+		}
+		query.MaxPrice = &maxPrice
+	}
+	if v := values.Get("in_stock"); v != "" {
+		if query.InStock, err = strconv.ParseBool(v); err != nil {
+			return query, fmt.Errorf("invalid in_stock: %q", v)
+		}
+	}
+
+	return query, nil
+}
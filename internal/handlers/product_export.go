@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+)
+
+// Export formats of the catalog
+const (
+	exportFormatCSV         = "csv"
+	exportFormatJSONL       = "jsonl"
+	exportFormatMerchantXML = "merchant-xml"
+)
+
+// A finished export ends with the trailer X-Export-Status: complete.
+// An export that fails after the first row has gone out has its connection aborted instead,
+// so clients see a failed transfer rather than a short file that still parses.
+const (
+	exportStatusTrailer  = "X-Export-Status"
+	exportStatusComplete = "complete"
+)
+
+// productWriter encodes a stream of products in one export format
+type productWriter interface {
+	Write(product *models.Product) error
+	// Close writes any trailer and flushes buffered output
+	Close() error
+}
+
+// Pick the writer, content type and file extension for an export format
+func newProductWriter(format string, w io.Writer) (productWriter, string, string, error) {
+	switch format {
+	case exportFormatCSV:
+		return newCSVProductWriter(w), "text/csv; charset=utf-8", "csv", nil
+	case exportFormatJSONL:
+		return &jsonlProductWriter{encoder: json.NewEncoder(w)}, "application/x-ndjson", "jsonl", nil
+	case exportFormatMerchantXML:
+		return newMerchantFeedWriter(w), "application/xml; charset=utf-8", "xml", nil
+	default:
+		return nil, "", "", fmt.Errorf("format must be one of %s, %s, %s", exportFormatCSV, exportFormatJSONL, exportFormatMerchantXML)
+	}
+}
+
+type csvProductWriter struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVProductWriter(w io.Writer) *csvProductWriter {
+	return &csvProductWriter{writer: csv.NewWriter(w)}
+}
+
+func (c *csvProductWriter) header() error {
+	if c.wroteHeader {
+		return nil
+	}
+	c.wroteHeader = true
+	return c.writer.Write([]string{"product_id", "name", "description", "price", "currency", "stock", "created_at", "updated_at"})
+}
+
+func (c *csvProductWriter) Write(product *models.Product) error {
+	if err := c.header(); err != nil {
+		return err
+	}
+
+	return c.writer.Write([]string{
+		product.ProductID,
+		product.Name,
+		product.Description,
+		product.Price.String(),
+		product.Currency,
+		strconv.Itoa(product.Stock),
+		product.CreatedAt.UTC().Format(time.RFC3339),
+		product.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (c *csvProductWriter) Close() error {
+	// An empty catalog still gets its header row
+	if err := c.header(); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlProductWriter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonlProductWriter) Write(product *models.Product) error {
+	return j.encoder.Encode(product)
+}
+
+func (j *jsonlProductWriter) Close() error {
+	return nil
+}
+
+// feedItem is an <item> of an RSS 2.0 shopping feed using the g: namespace
+type feedItem struct {
+	XMLName      xml.Name `xml:"item"`
+	ID           string   `xml:"g:id"`
+	Title        string   `xml:"g:title"`
+	Description  string   `xml:"g:description"`
+	Price        string   `xml:"g:price"`
+	Availability string   `xml:"g:availability"`
+}
+
+type merchantFeedWriter struct {
+	w           io.Writer
+	encoder     *xml.Encoder
+	wroteHeader bool
+}
+
+func newMerchantFeedWriter(w io.Writer) *merchantFeedWriter {
+	return &merchantFeedWriter{w: w, encoder: xml.NewEncoder(w)}
+}
+
+func (m *merchantFeedWriter) header() error {
+	if m.wroteHeader {
+		return nil
+	}
+	m.wroteHeader = true
+	_, err := io.WriteString(m.w, xml.Header+
+		`<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0">`+"\n"+
+		"<channel>\n<title>Product catalog</title>\n")
+	return err
+}
+
+func (m *merchantFeedWriter) Write(product *models.Product) error {
+	if err := m.header(); err != nil {
+		return err
+	}
+
+	availability := "out_of_stock"
+	if product.Stock > 0 {
+		availability = "in_stock"
+	}
+
+	if err := m.encoder.Encode(feedItem{
+		ID:           product.ProductID,
+		Title:        product.Name,
+		Description:  product.Description,
+		Price:        fmt.Sprintf("%s %s", product.Price, product.Currency),
+		Availability: availability,
+	}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(m.w, "\n")
+	return err
+}
+
+func (m *merchantFeedWriter) Close() error {
+	// An empty catalog still has to be a valid feed
+	if err := m.header(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(m.w, "</channel>\n</rss>\n")
+	return err
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/services"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+type RoleHandler struct {
+	service services.RoleService
+}
+
+func NewRoleHandler(service services.RoleService) *RoleHandler {
+	return &RoleHandler{
+		service: service,
+	}
+}
+
+func (h *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.service.GetAll(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, roles)
+}
+
+func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	// Get UserID from URL
+	userID := chi.URLParam(r, "id")
+
+	roles, err := h.service.GetByUser(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, roles)
+}
+
+func (h *RoleHandler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	var roleReq models.AssignRoleRequest
+
+	// Get UserID from URL
+	userID := chi.URLParam(r, "id")
+
+	// Decode Role from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &roleReq)
+	if err != nil {
+		log.Printf("Error decoding role data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	if err := h.service.Assign(r.Context(), userID, roleReq.Role); err != nil {
+		log.Printf("Error assigning role to user (ID: %s): %v", userID, err.Error())
+		switch {
+		case errors.Is(err, services.ErrMissingRole):
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrUserNotFound),
+			errors.Is(err, services.ErrRoleNotFound):
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Role %s assigned to user with id: %s successfully", roleReq.Role, userID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func (h *RoleHandler) RemoveUserRole(w http.ResponseWriter, r *http.Request) {
+	// Get UserID and Role from URL
+	userID := chi.URLParam(r, "id")
+	roleName := chi.URLParam(r, "role")
+
+	if err := h.service.Remove(r.Context(), userID, roleName); err != nil {
+		log.Printf("Error removing role from user (ID: %s): %v", userID, err.Error())
+		if errors.Is(err, services.ErrRoleNotAssigned) {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Role %s removed from user with id: %s successfully", roleName, userID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/services"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+type TaxHandler struct {
+	service services.TaxService
+}
+
+func NewTaxHandler(service services.TaxService) *TaxHandler {
+	return &TaxHandler{
+		service: service,
+	}
+}
+
+func (h *TaxHandler) GetAllTaxRules(w http.ResponseWriter, r *http.Request) {
+	// Superseded and retired versions are only listed on request
+	history := false
+	if value := r.URL.Query().Get("history"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "history must be true or false")
+			return
+		}
+		history = parsed
+	}
+
+	rules, err := h.service.GetAll(r.Context(), history)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, rules)
+}
+
+func (h *TaxHandler) GetTaxRuleByID(w http.ResponseWriter, r *http.Request) {
+	// Get TaxRuleID from URL
+	ruleID := chi.URLParam(r, "id")
+
+	rule, err := h.service.GetByID(r.Context(), ruleID)
+	if err != nil {
+		log.Printf("Error fetching tax rule (ID: %s): %v", ruleID, err.Error())
+		respondWithTaxRuleError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, rule)
+}
+
+func (h *TaxHandler) CreateTaxRule(w http.ResponseWriter, r *http.Request) {
+	var ruleReq models.TaxRuleRequest
+
+	// Decode Tax Rule from Request Body to Struct
+	errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &ruleReq)
+	if err != nil {
+		log.Printf("Error decoding tax rule data: %v", err)
+		utils.RespondWithError(w, statusCode, errMessage)
+		return
+	}
+
+	rule, err := h.service.Create(r.Context(), &ruleReq)
+	if err != nil {
+		log.Printf("Error creating tax rule: %v", err.Error())
+		respondWithTaxRuleError(w, err)
+		return
+	}
+
+	// Returning successful response
+	utils.RespondWithJSON(w, http.StatusCreated, rule)
+}
+
+func (h *TaxHandler) RetireTaxRule(w http.ResponseWriter, r *http.Request) {
+	// Get TaxRuleID from URL
+	ruleID := chi.URLParam(r, "id")
+
+	if err := h.service.Retire(r.Context(), ruleID); err != nil {
+		log.Printf("Error retiring tax rule (ID: %s): %v", ruleID, err.Error())
+		respondWithTaxRuleError(w, err)
+		return
+	}
+
+	// Returning successful response
+	res := fmt.Sprintf("Tax rule with id: %s retired successfully", ruleID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": res})
+}
+
+func respondWithTaxRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCountry),
+		errors.Is(err, services.ErrInvalidRegion),
+		errors.Is(err, services.ErrInvalidTaxClass),
+		errors.Is(err, services.ErrInvalidTaxRate):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrTaxRuleNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrTaxRuleConflict):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -1,16 +1,21 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/officiallysidsingh/ecom-server/internal/models"
 	"github.com/officiallysidsingh/ecom-server/internal/services"
 	"github.com/officiallysidsingh/ecom-server/internal/utils"
 )
 
+const (
+	accessTokenCookie  = "Authorization"
+	refreshTokenCookie = "RefreshToken"
+)
+
 type UserHandler struct {
 	service services.UserService
 }
@@ -33,22 +38,15 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Go to Login service
-	user, token, err := h.service.Login(r.Context(), &loginReq)
+	user, tokens, err := h.service.Login(r.Context(), &loginReq)
 	if err != nil {
 		log.Printf("Error logging in user: %v", err.Error())
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Set JWT in HTTP-only cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "Authorization",
-		Value:    token,
-		Expires:  time.Now().Add(15 * time.Minute),
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-	})
+	// Set tokens in HTTP-only cookies
+	setAuthCookies(w, tokens)
 
 	// Returning successful response
 	utils.RespondWithJSON(w, http.StatusOK, user)
@@ -66,24 +64,138 @@ func (h *UserHandler) Signup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Go to Signup service
-	userID, token, err := h.service.Signup(r.Context(), &signupReq)
+	userID, tokens, err := h.service.Signup(r.Context(), &signupReq)
 	if err != nil {
 		log.Printf("Error signing up in user: %v", err.Error())
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Set JWT in HTTP-only cookie
+	// Set tokens in HTTP-only cookies
+	setAuthCookies(w, tokens)
+
+	// Returning successful response
+	res := fmt.Sprintf("User with id: %s signed up successfully", userID)
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": res})
+}
+
+func (h *UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	// Browsers send the refresh token as a cookie, other clients in the body
+	var refreshToken string
+	if cookie, err := r.Cookie(refreshTokenCookie); err == nil {
+		refreshToken = cookie.Value
+	} else {
+		var refreshReq models.RefreshRequest
+
+		// Decode Refresh Token from Request Body to Struct
+		errMessage, statusCode, err := utils.ParseBodyToJSON(w, r, &refreshReq)
+		if err != nil {
+			log.Printf("Error decoding refresh data: %v", err)
+			utils.RespondWithError(w, statusCode, errMessage)
+			return
+		}
+		refreshToken = refreshReq.RefreshToken
+	}
+
+	// Go to Refresh service
+	tokens, err := h.service.Refresh(r.Context(), refreshToken)
+	if err != nil {
+		log.Printf("Error refreshing token: %v", err.Error())
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenInvalid),
+			errors.Is(err, services.ErrRefreshTokenExpired),
+			errors.Is(err, services.ErrRefreshTokenReused):
+			utils.RespondWithError(w, http.StatusUnauthorized, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Set tokens in HTTP-only cookies
+	setAuthCookies(w, tokens)
+
+	// Returning successful response
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Token refreshed successfully"})
+}
+
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	// The refresh token cookie is optional, the access token is revoked regardless
+	var refreshToken string
+	if cookie, err := r.Cookie(refreshTokenCookie); err == nil {
+		refreshToken = cookie.Value
+	}
+
+	// Go to Logout service
+	if err := h.service.Logout(r.Context(), refreshToken); err != nil {
+		log.Printf("Error logging out user: %v", err.Error())
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Remove tokens from the browser
+	clearAuthCookies(w)
+
+	// Returning successful response
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+}
+
+func (h *UserHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	// Go to LogoutAll service
+	if err := h.service.LogoutAll(r.Context()); err != nil {
+		log.Printf("Error logging out all sessions: %v", err.Error())
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Remove tokens from the browser
+	clearAuthCookies(w)
+
+	// Returning successful response
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Logged out of all sessions successfully"})
+}
+
+// Set the access and refresh tokens as HTTP-only cookies
+func setAuthCookies(w http.ResponseWriter, tokens *models.AuthTokens) {
 	http.SetCookie(w, &http.Cookie{
-		Name:     "Authorization",
-		Value:    token,
-		Expires:  time.Now().Add(15 * time.Minute),
+		Name:     accessTokenCookie,
+		Value:    tokens.AccessToken,
+		Expires:  tokens.AccessExpiresAt,
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   true,
 	})
 
-	// Returning successful response
-	res := fmt.Sprintf("User with id: %s signed up successfully", userID)
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": res})
+	// The refresh token is only ever needed by the /user routes
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    tokens.RefreshToken,
+		Expires:  tokens.RefreshExpiresAt,
+		Path:     "/user",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// Expire the access and refresh token cookies
+func clearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessTokenCookie,
+		Value:    "",
+		MaxAge:   -1,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    "",
+		MaxAge:   -1,
+		Path:     "/user",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
 }
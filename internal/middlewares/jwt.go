@@ -6,20 +6,23 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jmoiron/sqlx"
-	"github.com/officiallysidsingh/ecom-server/internal/config"
 	"github.com/officiallysidsingh/ecom-server/internal/models"
 	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
 )
 
 const userContextKey models.ContextKey = "user"
 
-func ValidateJWT(db *sqlx.DB, envConfig *config.EnvConfig) func(next http.Handler) http.Handler {
-	// Get the JWT secret from envConfig
-	secretKey := envConfig.JWT_SECRET
+var (
+	ErrMissingToken        = errors.New("missing Authorization header or cookie")
+	ErrMalformedAuthHeader = errors.New("malformed Authorization header, expected \"Bearer <token>\"")
+)
 
+func ValidateJWT(db *sqlx.DB, keys *utils.KeySet) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract the token from Authorization header or cookie
@@ -30,16 +33,18 @@ func ValidateJWT(db *sqlx.DB, envConfig *config.EnvConfig) func(next http.Handle
 			}
 
 			// Parse and validate the JWT
-			userID, userRole, err := parseJWT(db, r, tokenString, secretKey)
+			user, err := parseJWT(db, r, tokenString, keys)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusUnauthorized)
 				return
 			}
 
-			// userClaims
-			user := models.Claims{
-				UserID: userID,
-				Role:   userRole,
+			// Resolve what the user's roles allow them to do
+			roleStore := store.NewRoleStore(db)
+			user.Permissions, err = roleStore.GetPermissionsByUserFromDB(r.Context(), user.UserID)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("could not resolve permissions: %v", err), http.StatusInternalServerError)
+				return
 			}
 
 			// Set user in context for use in subsequent handlers
@@ -55,12 +60,24 @@ func ValidateJWT(db *sqlx.DB, envConfig *config.EnvConfig) func(next http.Handle
 	}
 }
 
+// The Authorization header takes precedence over the cookie.
+// A header that is present but malformed is rejected rather than falling back to the cookie.
 func extractToken(r *http.Request) (string, error) {
+	// Check the Authorization header first
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" || strings.ContainsAny(token, " \t") {
+			return "", ErrMalformedAuthHeader
+		}
+		return token, nil
+	}
+
 	// If not in the Authorization header, check the cookie
 	cookie, err := r.Cookie("Authorization")
 	if err != nil {
 		if err == http.ErrNoCookie {
-			return "", errors.New("missing Authorization cookie")
+			return "", ErrMissingToken
 		}
 		return "", fmt.Errorf("error retrieving cookie: %v", err)
 	}
@@ -68,38 +85,45 @@ func extractToken(r *http.Request) (string, error) {
 	return cookie.Value, nil
 }
 
-func parseJWT(db *sqlx.DB, r *http.Request, tokenString, secretKey string) (string, string, error) {
-	// Parse the JWT token
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		// Ensure the token is signed with the correct method
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return []byte(secretKey), nil
-	})
+func parseJWT(db *sqlx.DB, r *http.Request, tokenString string, keys *utils.KeySet) (models.Claims, error) {
+	// Parse the JWT token, verifying it with the key named by its kid header
+	var claims models.Claims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
 
 	// If token is invalid
 	if err != nil {
-		return "", "", fmt.Errorf("invalid token: %v", err)
+		return models.Claims{}, fmt.Errorf("invalid token: %v", err)
+	}
+	if !token.Valid || claims.UserID == "" || claims.ID == "" || claims.ExpiresAt == nil {
+		return models.Claims{}, errors.New("invalid token claims")
 	}
 
-	// If token is valid, extract user claims
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Extract info from claims
-		userID := claims["user_id"].(string)
+	// Initialize stores
+	userStore := store.NewUserStore(db)
+	tokenStore := store.NewTokenStore(db)
 
-		// Initialize userStore
-		userStore := store.NewUserStore(db)
+	// Reject tokens revoked on logout
+	revoked, err := tokenStore.IsAccessTokenRevokedFromDB(r.Context(), claims.ID)
+	if err != nil {
+		return models.Claims{}, fmt.Errorf("could not check token revocation: %v", err)
+	}
+	if revoked {
+		return models.Claims{}, errors.New("token has been revoked")
+	}
 
-		//Fetch user from DB
-		user, err := userStore.GetByIdFromDB(r.Context(), userID)
-		if err != nil {
-			return "", "", fmt.Errorf("could not fetch user from DB: %v", err)
-		}
+	//Fetch user from DB
+	user, err := userStore.GetByIdFromDB(r.Context(), claims.UserID)
+	if err != nil {
+		return models.Claims{}, fmt.Errorf("could not fetch user from DB: %v", err)
+	}
 
-		// Return the user
-		return user.UserID, user.Role, nil
+	// Reject tokens issued before the last "log out all sessions"
+	if claims.TokenVersion != user.TokenVersion {
+		return models.Claims{}, errors.New("token has been revoked")
 	}
 
-	return "", "", errors.New("invalid token claims")
+	// Return the claims with the current user data
+	claims.UserID = user.UserID
+	claims.Role = user.Role
+	return claims, nil
 }
@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractToken(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name        string
+		header      string
+		cookie      string
+		expectErrIs error
+		expectToken string
+	}{
+		{
+			name:        "Header only",
+			header:      "Bearer header-token",
+			expectToken: "header-token",
+		},
+		{
+			name:        "Cookie only",
+			cookie:      "cookie-token",
+			expectToken: "cookie-token",
+		},
+		{
+			name:        "Header takes precedence over the cookie",
+			header:      "Bearer header-token",
+			cookie:      "cookie-token",
+			expectToken: "header-token",
+		},
+		{
+			name:        "Scheme is case insensitive",
+			header:      "bearer header-token",
+			expectToken: "header-token",
+		},
+		{
+			name:        "Malformed header does not fall back to the cookie",
+			header:      "Token header-token",
+			cookie:      "cookie-token",
+			expectErrIs: ErrMalformedAuthHeader,
+		},
+		{
+			name:        "Basic scheme",
+			header:      "Basic dXNlcjpwYXNz",
+			expectErrIs: ErrMalformedAuthHeader,
+		},
+		{
+			name:        "Scheme without a token",
+			header:      "Bearer",
+			cookie:      "cookie-token",
+			expectErrIs: ErrMalformedAuthHeader,
+		},
+		{
+			name:        "Blank token",
+			header:      "Bearer   ",
+			expectErrIs: ErrMalformedAuthHeader,
+		},
+		{
+			name:        "Token without a scheme",
+			header:      "header-token",
+			expectErrIs: ErrMalformedAuthHeader,
+		},
+		{
+			name:        "Token containing spaces",
+			header:      "Bearer header token",
+			expectErrIs: ErrMalformedAuthHeader,
+		},
+		{
+			name:        "Neither header nor cookie",
+			expectErrIs: ErrMissingToken,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "Authorization", Value: tt.cookie})
+			}
+
+			token, err := extractToken(req)
+
+			if tt.expectErrIs != nil {
+				assert.ErrorIs(t, err, tt.expectErrIs)
+				assert.Empty(t, token)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectToken, token)
+			}
+		})
+	}
+}
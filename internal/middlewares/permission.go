@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+)
+
+// RequirePermission only lets a request through when the authenticated user holds every given permission.
+// It reads the permissions resolved by ValidateJWT, so it must be mounted after it.
+func RequirePermission(permissions ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get user set by ValidateJWT
+			user, ok := r.Context().Value(userContextKey).(models.Claims)
+			if !ok {
+				http.Error(w, "user not found in context", http.StatusUnauthorized)
+				return
+			}
+
+			for _, permission := range permissions {
+				if !user.HasPermission(permission) {
+					http.Error(w, "missing permission "+permission, http.StatusForbidden)
+					return
+				}
+			}
+
+			// Call the next handler
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+)
+
+// RequireRole only lets a request through when the authenticated user has one of the given roles.
+// It reads the claims set by ValidateJWT, so it must be mounted after it.
+func RequireRole(roles ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get user set by ValidateJWT
+			user, ok := r.Context().Value(userContextKey).(models.Claims)
+			if !ok {
+				http.Error(w, "user not found in context", http.StatusUnauthorized)
+				return
+			}
+
+			for _, role := range roles {
+				if user.Role == role {
+					// Call the next handler
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			http.Error(w, "insufficient role for this action", http.StatusForbidden)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+type Cart struct {
+	UserID     string     `json:"user_id"`
+	Currency   string     `json:"currency"`
+	Items      []CartItem `json:"items"`
+	TotalPrice Money      `json:"total_price"`
+}
+
+type CartItem struct {
+	ProductID  string         `db:"product_id" json:"product_id"`
+	VariantID  string         `db:"variant_id" json:"variant_id"`
+	SKU        string         `db:"sku" json:"sku"`
+	Options    VariantOptions `db:"options" json:"options"`
+	Name       string         `db:"name" json:"name"`
+	UnitPrice  Money          `db:"unit_price" json:"unit_price"`
+	Currency   string         `db:"currency" json:"-"`
+	Stock      int            `db:"stock" json:"stock"`
+	Quantity   int            `db:"quantity" json:"quantity"`
+	TotalPrice Money          `db:"-" json:"total_price"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+type AddCartItemRequest struct {
+	ProductID string `json:"product_id"`
+	// Empty means the default variant of the product
+	VariantID string `json:"variant_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type UpdateCartItemRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+type CheckoutRequest struct {
+	PaymentMethod string `json:"payment_method"`
+	// Empty means DefaultCurrency
+	Currency string `json:"currency"`
+	// Where the order is taxed. Country is required (ISO 3166-1 alpha-2, e.g. "US"); region is optional
+	Country string `json:"country"`
+	Region  string `json:"region"`
+}
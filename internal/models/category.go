@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type Category struct {
+	CategoryID  string    `db:"category_id" json:"category_id"`
+	ParentID    *string   `db:"parent_id" json:"parent_id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// CategoryNode is a category with its subcategories, as returned by the tree endpoint
+type CategoryNode struct {
+	Category
+	Depth    int             `db:"depth" json:"depth"`
+	Children []*CategoryNode `db:"-" json:"children"`
+}
+
+type CategoryRequest struct {
+	ParentID    *string `json:"parent_id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency is the currency of products and orders that don't name one
+const DefaultCurrency = "USD"
+
+// Rates are stored in DECIMAL(18, 8) columns
+const rateDecimals = 8
+
+var (
+	ErrInvalidCurrency = errors.New("currency must be a 3 letter ISO 4217 code")
+	ErrInvalidRate     = errors.New("invalid rate")
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NormalizeCurrency upper-cases a currency code and checks its shape, using DefaultCurrency when it is empty
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency, nil
+	}
+	if !currencyPattern.MatchString(code) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+	return code, nil
+}
+
+// Rate is an exact decimal multiplier, such as an exchange rate, with up to 8 decimal places.
+// Like Money it holds integer units, here 1e-8 of the rate, so it is safe to copy and compare.
+type Rate struct {
+	units int64
+}
+
+// Number of Rate units in a rate of 1
+const rateScale = 100_000_000
+
+// UnitRate is the rate that leaves an amount unchanged
+func UnitRate() Rate {
+	return Rate{units: rateScale}
+}
+
+// ParseRate reads a decimal rate such as "1.0845"
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return Rate{}, fmt.Errorf("%w: %q is not a number", ErrInvalidRate, s)
+	}
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %q is not a number", ErrInvalidRate, s)
+	}
+	units := rate.Mul(rate, big.NewRat(rateScale, 1))
+	if !units.IsInt() {
+		return Rate{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidRate, s, rateDecimals)
+	}
+	if !units.Num().IsInt64() {
+		return Rate{}, fmt.Errorf("%w: %q is out of range", ErrInvalidRate, s)
+	}
+	return Rate{units: units.Num().Int64()}, nil
+}
+
+// Rat returns the rate as a new big.Rat for arithmetic
+func (r Rate) Rat() *big.Rat {
+	return big.NewRat(r.units, rateScale)
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the rate
+func (r Rate) Sign() int {
+	switch {
+	case r.units < 0:
+		return -1
+	case r.units > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String formats the rate without trailing zeros, e.g. "1.0845"
+func (r Rate) String() string {
+	sign := ""
+	units := uint64(r.units)
+	if r.units < 0 {
+		sign = "-"
+		units = -units
+	}
+	whole, rest := units/rateScale, units%rateScale
+	if rest == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	fraction := strings.TrimRight(fmt.Sprintf("%0*d", rateDecimals, rest), "0")
+	return fmt.Sprintf("%s%d.%s", sign, whole, fraction)
+}
+
+// Scan implements the sql.Scanner interface for DECIMAL columns
+func (r *Rate) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		*r = Rate{}
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', rateDecimals, 64)
+	default:
+		return fmt.Errorf("cannot scan %T into Rate", value)
+	}
+	parsed, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value implements the driver.Valuer interface, writing the exact decimal text
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// MarshalJSON writes the rate as a JSON number
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or a numeric string without going through float64
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	parsed, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// ExchangeRate converts prices from BaseCurrency to QuoteCurrency: 1 BaseCurrency buys Rate QuoteCurrency
+type ExchangeRate struct {
+	BaseCurrency  string    `db:"base_currency" json:"base_currency"`
+	QuoteCurrency string    `db:"quote_currency" json:"quote_currency"`
+	Rate          Rate      `db:"rate" json:"rate"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type ExchangeRateRequest struct {
+	Rate Rate `json:"rate"`
+}
@@ -0,0 +1,145 @@
+package models_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRate(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name         string
+		input        string
+		expectErr    bool
+		expectString string
+		expectRat    *big.Rat
+	}{
+		{
+			name:         "Exchange rate",
+			input:        "1.0845",
+			expectString: "1.0845",
+			expectRat:    big.NewRat(10845, 10000),
+		},
+		{
+			name:         "Eight decimal places",
+			input:        "0.00000001",
+			expectString: "0.00000001",
+			expectRat:    big.NewRat(1, 100_000_000),
+		},
+		{
+			name:         "Trailing zeros beyond eight places",
+			input:        "0.082500000000",
+			expectString: "0.0825",
+			expectRat:    big.NewRat(825, 10000),
+		},
+		{
+			name:         "Whole number",
+			input:        " 2 ",
+			expectString: "2",
+			expectRat:    big.NewRat(2, 1),
+		},
+		{
+			name:         "Zero",
+			input:        "0.00000000",
+			expectString: "0",
+			expectRat:    new(big.Rat),
+		},
+		{
+			name:         "Negative",
+			input:        "-0.5",
+			expectString: "-0.5",
+			expectRat:    big.NewRat(-1, 2),
+		},
+		{
+			name:         "Largest DECIMAL(18, 8)",
+			input:        "9999999999.99999999",
+			expectString: "9999999999.99999999",
+			expectRat:    big.NewRat(999999999999999999, 100_000_000),
+		},
+		{
+			name:      "More than eight decimal places",
+			input:     "0.123456789",
+			expectErr: true,
+		},
+		{
+			name:      "Out of range",
+			input:     "100000000000",
+			expectErr: true,
+		},
+		{
+			name:      "Not a number",
+			input:     "1/3",
+			expectErr: true,
+		},
+		{
+			name:      "Empty",
+			input:     "",
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := models.ParseRate(tt.input)
+
+			if tt.expectErr {
+				assert.ErrorIs(t, err, models.ErrInvalidRate)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectString, rate.String())
+				assert.Equal(t, 0, tt.expectRat.Cmp(rate.Rat()), "got %s", rate.Rat())
+				assert.Equal(t, tt.expectRat.Sign(), rate.Sign())
+			}
+		})
+	}
+}
+
+func TestRateCopies(t *testing.T) {
+	rate, err := models.ParseRate("1.25")
+	assert.NoError(t, err)
+
+	// Copies and the Rat they hand out never share state with the original
+	copied := rate
+	rat := copied.Rat()
+	rat.Mul(rat, big.NewRat(10, 1))
+	assert.Equal(t, "1.25", rate.String())
+	assert.Equal(t, "1.25", copied.String())
+	assert.Equal(t, rate, copied)
+
+	// Equal rates compare equal however they were written
+	same, err := models.ParseRate("1.2500")
+	assert.NoError(t, err)
+	assert.Equal(t, rate, same)
+
+	assert.Equal(t, "1", models.UnitRate().String())
+}
+
+func TestRateJSONAndScan(t *testing.T) {
+	var req models.ExchangeRateRequest
+	assert.NoError(t, json.Unmarshal([]byte(`{"rate": 1.0845}`), &req))
+	assert.Equal(t, "1.0845", req.Rate.String())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"rate": "0.2"}`), &req))
+	assert.Equal(t, "0.2", req.Rate.String())
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"rate": 0.2}`, string(data))
+
+	// Postgres hands DECIMAL columns back as text
+	var rate models.Rate
+	assert.NoError(t, rate.Scan([]byte("1.10000000")))
+	assert.Equal(t, "1.1", rate.String())
+	assert.NoError(t, rate.Scan(nil))
+	assert.Equal(t, models.Rate{}, rate)
+	assert.Error(t, rate.Scan(true))
+
+	value, err := rate.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "0", value)
+}
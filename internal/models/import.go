@@ -0,0 +1,54 @@
+package models
+
+// Import formats accepted by the bulk product import
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+// Outcomes of an imported row
+const (
+	ImportActionCreated = "created"
+	ImportActionUpdated = "updated"
+	ImportActionFailed  = "failed"
+)
+
+// ProductImportRow is one product of an import file, keyed by the merchant's external ID.
+// A row describes the whole product, so optional fields it leaves out are set to their defaults.
+type ProductImportRow struct {
+	Line        int    `json:"-"`
+	ExternalID  string `json:"external_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
+	Currency    string `json:"currency"`
+	TaxClass    string `json:"tax_class"`
+	Stock       int    `json:"stock"`
+}
+
+// ImportTarget is the existing product an import row with the same external ID would update
+type ImportTarget struct {
+	Archived bool
+	// HasVariants is set when the product has variants besides its default one,
+	// in which case only its current Stock can be imported
+	HasVariants bool
+	Stock       int
+}
+
+type ImportRowResult struct {
+	Line       int    `json:"line"`
+	ExternalID string `json:"external_id,omitempty"`
+	ProductID  string `json:"product_id,omitempty"`
+	Action     string `json:"action"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ImportReport sums up an import; in a dry run the actions are what would have happened
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
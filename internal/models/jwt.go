@@ -1,11 +1,49 @@
 package models
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type ContextKey string
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID       string   `json:"user_id"`
+	Role         string   `json:"role"`
+	TokenVersion int      `json:"ver"`
+	Permissions  []string `json:"-"`
 	jwt.RegisteredClaims
 }
+
+// HasPermission reports whether the permissions resolved for the user include the given one
+func (c Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type RefreshToken struct {
+	TokenID    string     `db:"token_id" json:"token_id"`
+	UserID     string     `db:"user_id" json:"user_id"`
+	FamilyID   string     `db:"family_id" json:"family_id"`
+	TokenHash  string     `db:"token_hash" json:"-"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+	ReplacedBy *string    `db:"replaced_by" json:"replaced_by"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+type AuthTokens struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
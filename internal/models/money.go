@@ -0,0 +1,138 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// Plain decimal numbers, as written in JSON, CSV and by Postgres
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d{1,3})?$`)
+
+// Money is an exact amount in minor units (cents), matching the DECIMAL(10, 2) price columns.
+// It reads and writes those columns as decimal text and marshals to JSON as a plain number,
+// so sums of line items never pick up floating point error.
+type Money int64
+
+// ParseMoney reads a decimal amount such as "12.34". More than 2 decimal places is an error
+// rather than a silent rounding.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return 0, fmt.Errorf("%w: %q is not a number", ErrInvalidMoney, s)
+	}
+	amount, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q is not a number", ErrInvalidMoney, s)
+	}
+	cents := amount.Mul(amount, big.NewRat(100, 1))
+	if !cents.IsInt() {
+		return 0, fmt.Errorf("%w: %q has more than 2 decimal places", ErrInvalidMoney, s)
+	}
+	if !cents.Num().IsInt64() {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, s)
+	}
+	return Money(cents.Num().Int64()), nil
+}
+
+// String formats the amount with exactly 2 decimal places, e.g. "12.30"
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+	}
+	units, rest := cents/100, cents%100
+	if units < 0 {
+		units = -units
+	}
+	if rest < 0 {
+		rest = -rest
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, units, rest)
+}
+
+// Mul returns the amount times a quantity, such as the total of an order line
+func (m Money) Mul(quantity int) Money {
+	return m * Money(quantity)
+}
+
+// MulRate returns the amount times a rate, rounded to the cent.
+//
+// Rounding rules: the exact product is rounded half away from zero, so 0.005 becomes 0.01 and
+// -0.005 becomes -0.01. Tax is computed and rounded per order line, and the order tax is the
+// sum of the rounded line taxes, so every line on a receipt adds up to the order total.
+func (m Money) MulRate(rate *big.Rat) Money {
+	product := new(big.Rat).Mul(big.NewRat(int64(m), 1), rate)
+
+	// Truncate toward zero, then step away from zero when the remainder is at least a half
+	quotient, remainder := new(big.Int).QuoRem(product.Num(), product.Denom(), new(big.Int))
+	remainder.Abs(remainder).Mul(remainder, big.NewInt(2))
+	if remainder.Cmp(product.Denom()) >= 0 {
+		if product.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return Money(quotient.Int64())
+}
+
+// Scan implements the sql.Scanner interface for DECIMAL columns
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		return m.parse(string(v))
+	case string:
+		return m.parse(v)
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	case float64:
+		*m = Money(math.Round(v * 100))
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+}
+
+func (m *Money) parse(s string) error {
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implements the driver.Valuer interface, writing the exact decimal text
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// MarshalJSON writes the amount as a JSON number with 2 decimal places
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or a numeric string without going through float64
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	return m.parse(text)
+}
@@ -0,0 +1,193 @@
+package models_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name        string
+		input       string
+		expectErr   bool
+		expectMoney models.Money
+	}{
+		{name: "Two decimal places", input: "12.34", expectMoney: 1234},
+		{name: "One decimal place", input: "12.3", expectMoney: 1230},
+		{name: "Whole number", input: "12", expectMoney: 1200},
+		{name: "Trailing dot", input: "12.", expectMoney: 1200},
+		{name: "Leading dot", input: ".5", expectMoney: 50},
+		{name: "Surrounding whitespace", input: " 9.99 ", expectMoney: 999},
+		{name: "Trailing zeros beyond two places", input: "1.2300", expectMoney: 123},
+		{name: "Exponent", input: "1.5e2", expectMoney: 15000},
+		{name: "Negative", input: "-0.01", expectMoney: -1},
+		{name: "Explicit plus", input: "+3.10", expectMoney: 310},
+		{name: "Zero", input: "0.00", expectMoney: 0},
+		{name: "More than two decimal places", input: "1.234", expectErr: true},
+		{name: "Half a cent", input: "0.005", expectErr: true},
+		{name: "Out of range", input: "92233720368547758.08", expectErr: true},
+		{name: "Fraction", input: "1/2", expectErr: true},
+		{name: "Comma decimal separator", input: "1,50", expectErr: true},
+		{name: "Currency symbol", input: "$1.50", expectErr: true},
+		{name: "Empty", input: "", expectErr: true},
+		{name: "Infinity", input: "Inf", expectErr: true},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := models.ParseMoney(tt.input)
+
+			if tt.expectErr {
+				assert.ErrorIs(t, err, models.ErrInvalidMoney)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectMoney, money)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name         string
+		money        models.Money
+		expectString string
+	}{
+		{name: "Whole amount", money: 1200, expectString: "12.00"},
+		{name: "Cents", money: 1234, expectString: "12.34"},
+		{name: "Single cent", money: 1, expectString: "0.01"},
+		{name: "Zero", money: 0, expectString: "0.00"},
+		{name: "Negative", money: -1234, expectString: "-12.34"},
+		{name: "Negative cents only", money: -5, expectString: "-0.05"},
+		{name: "Negative whole amount", money: -100, expectString: "-1.00"},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectString, tt.money.String())
+
+			// Formatting and parsing round trip
+			parsed, err := models.ParseMoney(tt.money.String())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.money, parsed)
+		})
+	}
+}
+
+func TestMoneyMulRate(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name        string
+		money       models.Money
+		rate        *big.Rat
+		expectMoney models.Money
+	}{
+		{name: "Exact product", money: 1000, rate: big.NewRat(11, 10), expectMoney: 1100},
+		{name: "Half a cent rounds up", money: 1, rate: big.NewRat(1, 2), expectMoney: 1},
+		{name: "Negative half a cent rounds down", money: -1, rate: big.NewRat(1, 2), expectMoney: -1},
+		{name: "Just below half a cent rounds down", money: 1, rate: big.NewRat(49, 100), expectMoney: 0},
+		{name: "Negative just below half a cent rounds up", money: -1, rate: big.NewRat(49, 100), expectMoney: 0},
+		{name: "Negative rate", money: 1, rate: big.NewRat(-1, 2), expectMoney: -1},
+		{name: "Sales tax", money: 2020, rate: big.NewRat(825, 10000), expectMoney: 167},
+		{name: "Inclusive tax share", money: 10999, rate: big.NewRat(1, 6), expectMoney: 1833},
+		{name: "Currency conversion", money: 9999, rate: big.NewRat(11, 10), expectMoney: 10999},
+		{name: "Zero rate", money: 1234, rate: new(big.Rat), expectMoney: 0},
+		{name: "Zero amount", money: 0, rate: big.NewRat(3, 2), expectMoney: 0},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectMoney, tt.money.MulRate(tt.rate))
+		})
+	}
+
+	// 0.005 and -0.005 of a currency unit round away from zero to 0.01 and -0.01
+	half := big.NewRat(1, 200)
+	assert.Equal(t, "0.01", models.Money(100).MulRate(half).String())
+	assert.Equal(t, "-0.01", models.Money(-100).MulRate(half).String())
+}
+
+func TestMoneyScan(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name        string
+		value       interface{}
+		expectErr   bool
+		expectMoney models.Money
+	}{
+		{name: "Decimal text", value: []byte("12.34"), expectMoney: 1234},
+		{name: "String", value: "0.10", expectMoney: 10},
+		{name: "Integer", value: int64(12), expectMoney: 1200},
+		{name: "Float", value: 10.1, expectMoney: 1010},
+		{name: "Float without an exact binary form", value: 0.29, expectMoney: 29},
+		{name: "Negative float", value: -1.15, expectMoney: -115},
+		{name: "Null", value: nil, expectMoney: 0},
+		{name: "Too many decimal places", value: []byte("1.234"), expectErr: true},
+		{name: "Unsupported type", value: true, expectErr: true},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money := models.Money(999)
+			err := money.Scan(tt.value)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectMoney, money)
+			}
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name        string
+		input       string
+		expectErr   bool
+		expectMoney models.Money
+	}{
+		{name: "Number", input: `{"price": 12.34}`, expectMoney: 1234},
+		{name: "Numeric string", input: `{"price": "12.34"}`, expectMoney: 1234},
+		{name: "Whole number", input: `{"price": 12}`, expectMoney: 1200},
+		{name: "Null keeps the zero value", input: `{"price": null}`, expectMoney: 0},
+		{name: "Missing", input: `{}`, expectMoney: 0},
+		{name: "More than two decimal places", input: `{"price": 12.345}`, expectErr: true},
+		{name: "Not a number", input: `{"price": "twelve"}`, expectErr: true},
+		{name: "Boolean", input: `{"price": true}`, expectErr: true},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				Price models.Money `json:"price"`
+			}
+			err := json.Unmarshal([]byte(tt.input), &body)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectMoney, body.Price)
+			}
+		})
+	}
+
+	// Amounts are written as plain numbers with two decimal places
+	data, err := json.Marshal(map[string]models.Money{"price": -1050})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"price":-10.50}`, string(data))
+}
@@ -4,23 +4,82 @@ import (
 	"time"
 )
 
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusFulfilled OrderStatus = "fulfilled"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
 type Order struct {
-	OrderID       string    `db:"order_id" json:"order_id"`
-	UserID        string    `db:"user_id" json:"user_id"`
-	PaymentMethod string    `db:"payment_method" json:"payment_method"`
-	TaxPrice      float64   `db:"tax_price" json:"tax_price"`
-	ShippingPrice float64   `db:"shipping_price" json:"shipping_price"`
-	TotalPrice    float64   `db:"total_price" json:"total_price"`
-	CreatedAt     time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
-	Items         []OrderItem
+	OrderID       string      `db:"order_id" json:"order_id"`
+	UserID        string      `db:"user_id" json:"user_id"`
+	Status        OrderStatus `db:"status" json:"status"`
+	PaymentMethod string      `db:"payment_method" json:"payment_method"`
+	Currency      string      `db:"currency" json:"currency"`
+	// Where the order is taxed; orders placed before tax rules existed have no country
+	TaxCountry    *string     `db:"tax_country" json:"tax_country"`
+	TaxRegion     string      `db:"tax_region" json:"tax_region"`
+	TaxPrice      Money       `db:"tax_price" json:"tax_price"`
+	ShippingPrice Money       `db:"shipping_price" json:"shipping_price"`
+	TotalPrice    Money       `db:"total_price" json:"total_price"`
+	CreatedAt     time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time   `db:"updated_at" json:"updated_at"`
+	Items         []OrderItem `db:"-" json:"items"`
 }
 
 type OrderItem struct {
 	OrderItemID string  `db:"order_item_id" json:"order_item_id"`
 	OrderID     string  `db:"order_id" json:"order_id"`
 	ProductID   string  `db:"product_id" json:"product_id"`
+	VariantID   *string `db:"variant_id" json:"variant_id"`
+	SKU         *string `db:"sku" json:"sku"`
 	Quantity    int     `db:"quantity" json:"quantity"`
-	UnitPrice   float64 `db:"unit_price" json:"unit_price"`
-	TotalPrice  float64 `db:"total_price" json:"total_price"`
+	UnitPrice   Money   `db:"unit_price" json:"unit_price"`
+	TotalPrice  Money   `db:"total_price" json:"total_price"`
+	// The currency the product was priced in and the rate that converted it into the order currency at checkout
+	ProductCurrency string `db:"product_currency" json:"product_currency"`
+	ExchangeRate    Rate   `db:"exchange_rate" json:"exchange_rate"`
+	// The tax rule version the line was taxed with, nil when no rule applied, and the tax it produced
+	TaxRuleID    *string          `db:"tax_rule_id" json:"tax_rule_id"`
+	TaxRate      Rate             `db:"tax_rate" json:"tax_rate"`
+	TaxInclusive bool             `db:"tax_inclusive" json:"tax_inclusive"`
+	TaxPrice     Money            `db:"tax_price" json:"tax_price"`
+	Product      OrderItemProduct `db:"product" json:"product"`
+}
+
+type OrderItemProduct struct {
+	Name  string `db:"name" json:"name"`
+	Price Money  `db:"price" json:"price"`
+}
+
+type CreateOrderRequest struct {
+	PaymentMethod string `json:"payment_method"`
+	// Empty means DefaultCurrency
+	Currency string `json:"currency"`
+	// Where the order is taxed. Country is required (ISO 3166-1 alpha-2, e.g. "US"); region is optional
+	Country string                   `json:"country"`
+	Region  string                   `json:"region"`
+	Items   []CreateOrderItemRequest `json:"items"`
+}
+
+type CreateOrderItemRequest struct {
+	ProductID string `json:"product_id"`
+	// Empty means the default variant of the product
+	VariantID string `json:"variant_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type OrderStatusChange struct {
+	HistoryID  string       `db:"history_id" json:"history_id"`
+	OrderID    string       `db:"order_id" json:"order_id"`
+	FromStatus *OrderStatus `db:"from_status" json:"from_status"`
+	ToStatus   OrderStatus  `db:"to_status" json:"to_status"`
+	ChangedBy  *string      `db:"changed_by" json:"changed_by"`
+	ChangedAt  time.Time    `db:"changed_at" json:"changed_at"`
 }
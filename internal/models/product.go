@@ -1,15 +1,163 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
 type Product struct {
-	ProductID   string    `db:"product_id" json:"product_id"`
-	Name        string    `db:"name" json:"name"`
-	Description string    `db:"description" json:"description"`
-	Price       float64   `db:"price" json:"price"`
-	Stock       int       `db:"stock" json:"stock"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ProductID   string           `db:"product_id" json:"product_id"`
+	Name        string           `db:"name" json:"name"`
+	Description string           `db:"description" json:"description"`
+	Price       Money            `db:"price" json:"price"`
+	Currency    string           `db:"currency" json:"currency"`
+	TaxClass    string           `db:"tax_class" json:"tax_class"`
+	Stock       int              `db:"stock" json:"stock"`
+	CreatedAt   time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time        `db:"updated_at" json:"updated_at"`
+	Version     int              `db:"version" json:"-"`
+	Variants    []ProductVariant `db:"-" json:"variants,omitempty"`
+	Images      []ProductImage   `db:"-" json:"images,omitempty"`
+}
+
+// ProductPatch is a partial product update. Fields left out of the request stay nil and are not
+// changed, so a patch can still set stock to 0 or clear the description.
+// Stock is kept on the default variant, so a product with other variants only accepts its current
+// total here and is restocked or sold out through its variants instead.
+type ProductPatch struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Price       *Money  `json:"price"`
+	Currency    *string `json:"currency"`
+	TaxClass    *string `json:"tax_class"`
+	Stock       *int    `json:"stock"`
+}
+
+// Sort fields accepted by the product listing
+const (
+	ProductSortPrice     = "price"
+	ProductSortCreatedAt = "created_at"
+	ProductSortName      = "name"
+)
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// ProductQuery holds the paging, sorting and filtering options of the product listing
+type ProductQuery struct {
+	Search     string
+	CategoryID string
+	Limit      int
+	Offset     int
+	Cursor     string
+	Sort       string
+	Order      string
+	MinPrice   *Money
+	MaxPrice   *Money
+	InStock    bool
+	// Currency converts prices on the way out; empty leaves them in each product's own currency
+	Currency string
+}
+
+type Pagination struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ProductPage struct {
+	Items      []Product  `json:"items"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// ProductSearchResult is a product matched by full-text search with its rank and highlighted snippet
+type ProductSearchResult struct {
+	Product
+	Rank float64 `db:"rank" json:"rank"`
+	// HTML escaped snippet where the only markup is <mark> around matched words
+	Highlight string `db:"highlight" json:"highlight"`
+}
+
+type ProductSearchPage struct {
+	Items      []ProductSearchResult `json:"items"`
+	Pagination Pagination            `json:"pagination"`
+}
+
+// VariantOptions are the option attributes of a variant, such as size and colour
+type VariantOptions map[string]string
+
+// Scan implements the sql.Scanner interface for JSONB columns
+func (o *VariantOptions) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*o = VariantOptions{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into VariantOptions", value)
+	}
+	return json.Unmarshal(data, o)
+}
+
+// Value implements the driver.Valuer interface for JSONB columns
+func (o VariantOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(o)
+}
+
+// ProductVariant is a sellable SKU of a product.
+// Price overrides the product price when set; EffectivePrice is what the variant sells for.
+type ProductVariant struct {
+	VariantID      string         `db:"variant_id" json:"variant_id"`
+	ProductID      string         `db:"product_id" json:"product_id"`
+	SKU            string         `db:"sku" json:"sku"`
+	Options        VariantOptions `db:"options" json:"options"`
+	Price          *Money         `db:"price" json:"price"`
+	EffectivePrice Money          `db:"effective_price" json:"effective_price"`
+	Stock          int            `db:"stock" json:"stock"`
+	IsDefault      bool           `db:"is_default" json:"is_default"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+type VariantRequest struct {
+	SKU     string         `json:"sku"`
+	Options VariantOptions `json:"options"`
+	Price   *Money         `json:"price"`
+	Stock   int            `json:"stock"`
+}
+
+// ProductImage is an uploaded image of a product, shown in position order.
+// URL is filled in from the storage backend that holds the file.
+type ProductImage struct {
+	ImageID     string           `db:"image_id" json:"image_id"`
+	ProductID   string           `db:"product_id" json:"product_id"`
+	StorageKey  string           `db:"storage_key" json:"-"`
+	URL         string           `db:"-" json:"url"`
+	ContentType string           `db:"content_type" json:"content_type"`
+	SizeBytes   int64            `db:"size_bytes" json:"size_bytes"`
+	Position    int              `db:"position" json:"position"`
+	CreatedAt   time.Time        `db:"created_at" json:"created_at"`
+	Thumbnails  []ImageThumbnail `db:"-" json:"thumbnails"`
+}
+
+// ImageThumbnail is a scaled copy of a product image whose longest edge is at most Size pixels
+type ImageThumbnail struct {
+	ImageID    string `db:"image_id" json:"-"`
+	Size       int    `db:"size" json:"size"`
+	StorageKey string `db:"storage_key" json:"-"`
+	URL        string `db:"-" json:"url"`
+	Width      int    `db:"width" json:"width"`
+	Height     int    `db:"height" json:"height"`
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	PermissionProductsWrite  = "products:write"
+	PermissionOrdersReadAny  = "orders:read:any"
+	PermissionOrdersWriteAny = "orders:write:any"
+	PermissionOrdersRefund   = "orders:refund"
+	PermissionRolesManage    = "roles:manage"
+	PermissionPricingManage  = "pricing:manage"
+)
+
+type Role struct {
+	RoleID      string         `db:"role_id" json:"role_id"`
+	Name        string         `db:"name" json:"name"`
+	Description string         `db:"description" json:"description"`
+	Permissions pq.StringArray `db:"permissions" json:"permissions"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+}
+
+type UserRole struct {
+	RoleID     string    `db:"role_id" json:"role_id"`
+	Name       string    `db:"name" json:"name"`
+	AssignedBy *string   `db:"assigned_by" json:"assigned_by"`
+	AssignedAt time.Time `db:"assigned_at" json:"assigned_at"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role"`
+}
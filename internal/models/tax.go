@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTaxClass is the tax class of products that don't name one
+const DefaultTaxClass = "standard"
+
+var (
+	ErrInvalidCountry  = errors.New("country must be a 2 letter ISO 3166-1 code")
+	ErrInvalidRegion   = errors.New("region must be an ISO 3166-2 subdivision code without the country prefix")
+	ErrInvalidTaxClass = errors.New("tax class must be 1 to 50 lowercase letters, digits, '-' or '_'")
+)
+
+var (
+	countryPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	regionPattern   = regexp.MustCompile(`^[A-Z0-9]{1,3}$`)
+	taxClassPattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+)
+
+// NormalizeCountry upper-cases a country code and checks its shape
+func NormalizeCountry(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !countryPattern.MatchString(code) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCountry, code)
+	}
+	return code, nil
+}
+
+// NormalizeRegion upper-cases a region code such as "CA" and checks its shape. Empty means no region
+func NormalizeRegion(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code != "" && !regionPattern.MatchString(code) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRegion, code)
+	}
+	return code, nil
+}
+
+// NormalizeTaxClass lower-cases a tax class and checks its shape, using DefaultTaxClass when it is empty
+func NormalizeTaxClass(class string) (string, error) {
+	class = strings.ToLower(strings.TrimSpace(class))
+	if class == "" {
+		return DefaultTaxClass, nil
+	}
+	if !taxClassPattern.MatchString(class) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTaxClass, class)
+	}
+	return class, nil
+}
+
+// TaxRule is one version of the tax rate for a tax class in a country or region.
+// A rule with an empty Region covers every region of the country that has no rule of its own.
+type TaxRule struct {
+	TaxRuleID string `db:"tax_rule_id" json:"tax_rule_id"`
+	Country   string `db:"country" json:"country"`
+	Region    string `db:"region" json:"region"`
+	TaxClass  string `db:"tax_class" json:"tax_class"`
+	Rate      Rate   `db:"rate" json:"rate"`
+	// Inclusive rules treat prices as already containing the tax
+	Inclusive    bool       `db:"inclusive" json:"inclusive"`
+	Version      int        `db:"version" json:"version"`
+	CreatedBy    *string    `db:"created_by" json:"created_by"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	SupersededAt *time.Time `db:"superseded_at" json:"superseded_at"`
+}
+
+type TaxRuleRequest struct {
+	Country   string `json:"country"`
+	Region    string `json:"region"`
+	TaxClass  string `json:"tax_class"`
+	Rate      Rate   `json:"rate"`
+	Inclusive bool   `json:"inclusive"`
+}
+
+// TaxRules are the rules in force for one country and region, keyed by tax class
+type TaxRules map[string]TaxRule
+
+// Apply taxes an order line with the rule for its tax class, recording the rule version used.
+// Lines without a rule are untaxed.
+func (rules TaxRules) Apply(item *OrderItem, taxClass string) {
+	rule, ok := rules[taxClass]
+	if !ok {
+		item.TaxRuleID = nil
+		item.TaxRate = Rate{}
+		item.TaxInclusive = false
+		item.TaxPrice = 0
+		return
+	}
+
+	ruleID := rule.TaxRuleID
+	item.TaxRuleID = &ruleID
+	item.TaxRate = rule.Rate
+	item.TaxInclusive = rule.Inclusive
+	item.TaxPrice = LineTax(item.TotalPrice, rule.Rate, rule.Inclusive)
+}
+
+// LineTax returns the tax on the total of an order line, rounded to the cent with Money.MulRate.
+// An exclusive rate is added on top of the amount; an inclusive rate is already part of it,
+// so the tax is the share rate / (1 + rate) of the amount.
+func LineTax(amount Money, rate Rate, inclusive bool) Money {
+	share := rate.Rat()
+	if inclusive {
+		gross := new(big.Rat).Add(big.NewRat(1, 1), share)
+		share.Quo(share, gross)
+	}
+	return amount.MulRate(share)
+}
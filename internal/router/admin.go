@@ -0,0 +1,58 @@
+package router
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/handlers"
+	"github.com/officiallysidsingh/ecom-server/internal/middlewares"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/services"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+func adminRoutes(db *sqlx.DB, keys *utils.KeySet) chi.Router {
+	// Initialize dependencies
+	userStore := store.NewUserStore(db)
+	roleStore := store.NewRoleStore(db)
+	roleService := services.NewRoleService(roleStore, userStore)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	rateStore := store.NewExchangeRateStore(db)
+	rateService := services.NewExchangeRateService(rateStore)
+	rateHandler := handlers.NewExchangeRateHandler(rateService)
+	taxStore := store.NewTaxStore(db)
+	taxService := services.NewTaxService(taxStore)
+	taxHandler := handlers.NewTaxHandler(taxService)
+
+	// Set up router
+	r := chi.NewRouter()
+
+	// JWT Auth Validation Middleware
+	r.Use(middlewares.ValidateJWT(db, keys))
+
+	// Role Management
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RequirePermission(models.PermissionRolesManage))
+
+		r.Get("/roles", roleHandler.GetAllRoles)
+		r.Get("/users/{id}/roles", roleHandler.GetUserRoles)
+		r.Post("/users/{id}/roles", roleHandler.AssignUserRole)
+		r.Delete("/users/{id}/roles/{role}", roleHandler.RemoveUserRole)
+	})
+
+	// Pricing Data
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RequirePermission(models.PermissionPricingManage))
+
+		r.Get("/exchange-rates", rateHandler.GetAllExchangeRates)
+		r.Put("/exchange-rates/{base}/{quote}", rateHandler.SetExchangeRate)
+		r.Delete("/exchange-rates/{base}/{quote}", rateHandler.DeleteExchangeRate)
+
+		r.Get("/tax-rules", taxHandler.GetAllTaxRules)
+		r.Get("/tax-rules/{id}", taxHandler.GetTaxRuleByID)
+		r.Post("/tax-rules", taxHandler.CreateTaxRule)
+		r.Delete("/tax-rules/{id}", taxHandler.RetireTaxRule)
+	})
+
+	return r
+}
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/handlers"
+	"github.com/officiallysidsingh/ecom-server/internal/middlewares"
+	"github.com/officiallysidsingh/ecom-server/internal/services"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+func cartRoutes(db *sqlx.DB, keys *utils.KeySet) chi.Router {
+	// Initialize dependencies
+	orderStore := store.NewOrderStore(db)
+	taxStore := store.NewTaxStore(db)
+	taxService := services.NewTaxService(taxStore)
+	orderService := services.NewOrderService(orderStore, taxService)
+	cartStore := store.NewCartStore(db)
+	rateStore := store.NewExchangeRateStore(db)
+	cartService := services.NewCartService(cartStore, rateStore, orderService)
+	cartHandler := handlers.NewCartHandler(cartService)
+
+	// Set up router
+	r := chi.NewRouter()
+
+	// JWT Auth Validation Middleware
+	r.Use(middlewares.ValidateJWT(db, keys))
+
+	// Routes
+	r.Get("/", cartHandler.GetCart)
+	r.Delete("/", cartHandler.ClearCart)
+	r.Post("/items", cartHandler.AddCartItem)
+	r.Patch("/items/{variantID}", cartHandler.UpdateCartItem)
+	r.Delete("/items/{variantID}", cartHandler.RemoveCartItem)
+	r.Post("/checkout", cartHandler.Checkout)
+
+	return r
+}
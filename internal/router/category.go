@@ -0,0 +1,53 @@
+package router
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/handlers"
+	"github.com/officiallysidsingh/ecom-server/internal/middlewares"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/services"
+	"github.com/officiallysidsingh/ecom-server/internal/storage"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+	"github.com/officiallysidsingh/ecom-server/internal/workers"
+)
+
+func categoryRoutes(
+	db *sqlx.DB,
+	keys *utils.KeySet,
+	files storage.Backend,
+	thumbnails workers.ThumbnailQueue,
+) chi.Router {
+	// Initialize dependencies
+	productStore := store.NewProductStore(db)
+	variantStore := store.NewVariantStore(db)
+	imageStore := store.NewImageStore(db)
+	rateStore := store.NewExchangeRateStore(db)
+	productService := services.NewProductService(productStore, variantStore, imageStore, rateStore, files, thumbnails)
+	categoryStore := store.NewCategoryStore(db)
+	categoryService := services.NewCategoryService(categoryStore, productService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+
+	// Set up router
+	r := chi.NewRouter()
+
+	// Public Routes
+	r.Get("/", categoryHandler.GetCategoryTree)
+	r.Get("/{id}", categoryHandler.GetCategoryById)
+	r.Get("/{id}/products", categoryHandler.GetCategoryProducts)
+
+	// Catalog Writes
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.ValidateJWT(db, keys))
+		r.Use(middlewares.RequirePermission(models.PermissionProductsWrite))
+
+		r.Post("/", categoryHandler.AddCategory)
+		r.Put("/{id}", categoryHandler.UpdateCategory)
+		r.Delete("/{id}", categoryHandler.DeleteCategory)
+		r.Put("/{id}/products/{productID}", categoryHandler.AddCategoryProduct)
+		r.Delete("/{id}/products/{productID}", categoryHandler.RemoveCategoryProduct)
+	})
+
+	return r
+}
@@ -3,28 +3,46 @@ package router
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
-	"github.com/officiallysidsingh/ecom-server/internal/config"
 	"github.com/officiallysidsingh/ecom-server/internal/handlers"
 	"github.com/officiallysidsingh/ecom-server/internal/middlewares"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
 	"github.com/officiallysidsingh/ecom-server/internal/services"
 	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
 )
 
-func orderRoutes(db *sqlx.DB, envConfig *config.EnvConfig) chi.Router {
+func orderRoutes(db *sqlx.DB, keys *utils.KeySet) chi.Router {
 	// Initialize dependencies
 	orderStore := store.NewOrderStore(db)
-	orderService := services.NewOrderService(orderStore)
+	taxStore := store.NewTaxStore(db)
+	taxService := services.NewTaxService(taxStore)
+	orderService := services.NewOrderService(orderStore, taxService)
 	orderHandler := handlers.NewOrderHandler(orderService)
 
 	// Set up router
 	r := chi.NewRouter()
 
 	// JWT Auth Validation Middleware
-	r.Use(middlewares.ValidateJWT(db, envConfig))
+	r.Use(middlewares.ValidateJWT(db, keys))
 
 	// Routes
 	r.Get("/", orderHandler.GetAllOrders)
 	r.Get("/{id}", orderHandler.GetOrderById)
+	r.Post("/", orderHandler.CreateOrder)
+	r.Get("/{id}/history", orderHandler.GetOrderStatusHistory)
+	r.Post("/{id}/cancel", orderHandler.CancelOrder)
+
+	// Status Transitions (Staff Only)
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RequirePermission(models.PermissionOrdersWriteAny))
+
+		r.Post("/{id}/pay", orderHandler.TransitionOrder(models.OrderStatusPaid))
+		r.Post("/{id}/fulfill", orderHandler.TransitionOrder(models.OrderStatusFulfilled))
+		r.Post("/{id}/ship", orderHandler.TransitionOrder(models.OrderStatusShipped))
+		r.Post("/{id}/deliver", orderHandler.TransitionOrder(models.OrderStatusDelivered))
+	})
+	r.With(middlewares.RequirePermission(models.PermissionOrdersRefund)).
+		Post("/{id}/refund", orderHandler.TransitionOrder(models.OrderStatusRefunded))
 
 	return r
 }
@@ -4,26 +4,82 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
 	"github.com/officiallysidsingh/ecom-server/internal/handlers"
+	"github.com/officiallysidsingh/ecom-server/internal/middlewares"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
 	"github.com/officiallysidsingh/ecom-server/internal/services"
+	"github.com/officiallysidsingh/ecom-server/internal/storage"
 	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+	"github.com/officiallysidsingh/ecom-server/internal/workers"
 )
 
-func productRoutes(db *sqlx.DB) chi.Router {
-	// Initialize dependencies
+func newProductHandler(
+	db *sqlx.DB,
+	files storage.Backend,
+	thumbnails workers.ThumbnailQueue,
+) *handlers.ProductHandler {
 	productStore := store.NewProductStore(db)
-	productService := services.NewProductService(productStore)
-	productHandler := handlers.NewProductHandler(productService)
+	variantStore := store.NewVariantStore(db)
+	imageStore := store.NewImageStore(db)
+	rateStore := store.NewExchangeRateStore(db)
+	productService := services.NewProductService(productStore, variantStore, imageStore, rateStore, files, thumbnails)
+	return handlers.NewProductHandler(productService)
+}
+
+func productRoutes(
+	db *sqlx.DB,
+	keys *utils.KeySet,
+	files storage.Backend,
+	thumbnails workers.ThumbnailQueue,
+) chi.Router {
+	// Initialize dependencies
+	productHandler := newProductHandler(db, files, thumbnails)
 
 	// Set up router
 	r := chi.NewRouter()
 
-	// Routes
+	// Public Routes
 	r.Get("/", productHandler.GetAllProducts)
+	r.Get("/search", productHandler.SearchProducts)
 	r.Get("/{id}", productHandler.GetProductById)
-	r.Post("/", productHandler.AddProduct)
-	r.Put("/{id}", productHandler.PutUpdateProduct)
-	r.Patch("/{id}", productHandler.PatchUpdateProduct)
-	r.Delete("/{id}", productHandler.DeleteProduct)
+
+	// Catalog Writes
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.ValidateJWT(db, keys))
+		r.Use(middlewares.RequirePermission(models.PermissionProductsWrite))
+
+		r.Post("/", productHandler.AddProduct)
+		r.Post("/import", productHandler.ImportProducts)
+		r.Put("/{id}", productHandler.PutUpdateProduct)
+		r.Patch("/{id}", productHandler.PatchUpdateProduct)
+		r.Delete("/{id}", productHandler.DeleteProduct)
+		r.Post("/{id}/restore", productHandler.RestoreProduct)
+		r.Post("/{id}/variants", productHandler.AddProductVariant)
+		r.Put("/{id}/variants/{variantID}", productHandler.UpdateProductVariant)
+		r.Delete("/{id}/variants/{variantID}", productHandler.DeleteProductVariant)
+		r.Post("/{id}/images", productHandler.UploadProductImage)
+	})
+
+	return r
+}
+
+// The catalog export streams for as long as the client keeps reading,
+// so it is mounted outside the request timeout
+func productExportRoutes(
+	db *sqlx.DB,
+	keys *utils.KeySet,
+	files storage.Backend,
+	thumbnails workers.ThumbnailQueue,
+) chi.Router {
+	// Initialize dependencies
+	productHandler := newProductHandler(db, files, thumbnails)
+
+	// Set up router
+	r := chi.NewRouter()
+	r.Use(middlewares.ValidateJWT(db, keys))
+	r.Use(middlewares.RequirePermission(models.PermissionProductsWrite))
+
+	r.Get("/", productHandler.ExportProducts)
 
 	return r
 }
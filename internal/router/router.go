@@ -6,18 +6,28 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jmoiron/sqlx"
-	"github.com/officiallysidsingh/ecom-server/internal/config"
 	"github.com/officiallysidsingh/ecom-server/internal/handlers"
+	"github.com/officiallysidsingh/ecom-server/internal/storage"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+	"github.com/officiallysidsingh/ecom-server/internal/workers"
 )
 
-func Setup(db *sqlx.DB, envConfig *config.EnvConfig) *chi.Mux {
+// Requests running longer than this are cancelled and answered with a 504
+var requestTimeout = 15 * time.Second
+
+func Setup(
+	db *sqlx.DB,
+	keys *utils.KeySet,
+	files storage.Backend,
+	thumbnails workers.ThumbnailQueue,
+) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Middlewares
 	setupGlobalMiddlewares(r)
 
 	// Routes
-	setupRoutes(db, envConfig, r)
+	setupRoutes(db, keys, files, thumbnails, r)
 
 	return r
 }
@@ -25,15 +35,40 @@ func Setup(db *sqlx.DB, envConfig *config.EnvConfig) *chi.Mux {
 func setupGlobalMiddlewares(r *chi.Mux) {
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(15 * time.Second))
 }
 
-func setupRoutes(db *sqlx.DB, envConfig *config.EnvConfig, r *chi.Mux) {
-	// Health Check
-	r.Get("/", handlers.Health)
+func setupRoutes(
+	db *sqlx.DB,
+	keys *utils.KeySet,
+	files storage.Backend,
+	thumbnails workers.ThumbnailQueue,
+	r *chi.Mux,
+) {
+	// Streaming routes, which outlive the request timeout
+	r.Mount("/products/export", productExportRoutes(db, keys, files, thumbnails))
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(requestTimeout))
+
+		// Health Check
+		r.Get("/", handlers.Health)
+
+		// Public keys for verifying our JWTs
+		jwksHandler := handlers.NewJWKSHandler(keys)
+		r.Get("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
+		// Uploaded files, for storage backends served by this app
+		if server, ok := files.(storage.Server); ok {
+			mountPath, handler := server.Handler()
+			r.Handle(mountPath+"/*", handler)
+		}
 
-	// Sub-Routers
-	r.Mount("/products", productRoutes(db))
-	r.Mount("/orders", orderRoutes(db, envConfig))
-	r.Mount("/user", userRoutes(db, envConfig))
+		// Sub-Routers
+		r.Mount("/products", productRoutes(db, keys, files, thumbnails))
+		r.Mount("/categories", categoryRoutes(db, keys, files, thumbnails))
+		r.Mount("/orders", orderRoutes(db, keys))
+		r.Mount("/cart", cartRoutes(db, keys))
+		r.Mount("/user", userRoutes(db, keys))
+		r.Mount("/admin", adminRoutes(db, keys))
+	})
 }
@@ -0,0 +1,156 @@
+package router
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Load a key set with a single fresh Ed25519 signing key
+func newTestKeySet(t *testing.T) *utils.KeySet {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.pem"), data, 0o600))
+
+	keys, err := utils.LoadKeySet(dir, "test")
+	require.NoError(t, err)
+	return keys
+}
+
+// Expect the queries ValidateJWT runs for a user allowed to write products
+func expectCatalogWriter(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`FROM revoked_tokens`).
+		WithArgs("jti-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`FROM users`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "name", "email", "role", "token_version"}).
+			AddRow("user-1", "Admin", "admin@example.com", "admin", 0))
+	mock.ExpectQuery(`FROM user_roles`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(models.PermissionProductsWrite))
+}
+
+func TestExportOutlivesRequestTimeout(t *testing.T) {
+	// Shrink the request timeout so the test stays fast
+	defaultTimeout := requestTimeout
+	requestTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { requestTimeout = defaultTimeout })
+
+	keys := newTestKeySet(t)
+	token, err := keys.Sign(models.Claims{
+		UserID: "user-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti-1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	require.NoError(t, err)
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	productColumns := []string{"product_id", "name", "description", "price", "currency", "stock", "created_at", "updated_at"}
+
+	// Write testcases
+	tests := []struct {
+		name           string
+		mock           func(mock sqlmock.Sqlmock)
+		expectAborted  bool
+		expectBody     string
+		expectTrailer  string
+		expectDuration time.Duration
+	}{
+		{
+			name: "Slow export runs past the request timeout",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectCatalogWriter(mock)
+				mock.ExpectQuery(`FROM products`).
+					WillDelayFor(4 * requestTimeout).
+					WillReturnRows(sqlmock.NewRows(productColumns).
+						AddRow("p1", "Phone", "A phone", "99.99", "USD", 5, now, now).
+						AddRow("p2", "Case", "A case", "9.50", "USD", 0, now, now))
+			},
+			expectBody: "product_id,name,description,price,currency,stock,created_at,updated_at\n" +
+				"p1,Phone,A phone,99.99,USD,5,2025-01-02T03:04:05Z,2025-01-02T03:04:05Z\n" +
+				"p2,Case,A case,9.50,USD,0,2025-01-02T03:04:05Z,2025-01-02T03:04:05Z\n",
+			expectTrailer:  "complete",
+			expectDuration: 4 * requestTimeout,
+		},
+		{
+			name: "Failure after the first row aborts the transfer",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectCatalogWriter(mock)
+				mock.ExpectQuery(`FROM products`).
+					WillReturnRows(sqlmock.NewRows(productColumns).
+						AddRow("p1", "Phone", "A phone", "99.99", "USD", 5, now, now).
+						AddRow("p2", "Case", "A case", "9.50", "USD", 0, now, now).
+						RowError(1, errors.New("connection reset")))
+			},
+			expectAborted: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			db := sqlx.NewDb(mockDB, "postgres")
+			defer db.Close()
+			tt.mock(mock)
+
+			server := httptest.NewServer(Setup(db, keys, nil, nil))
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/products/export?format=csv", nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			started := time.Now()
+			resp, err := server.Client().Do(req)
+			if tt.expectAborted {
+				// Depending on buffering the abort shows up before or during the body
+				if err == nil {
+					_, err = io.ReadAll(resp.Body)
+					resp.Body.Close()
+					assert.NotEqual(t, "complete", resp.Trailer.Get("X-Export-Status"))
+				}
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, time.Since(started), tt.expectDuration)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tt.expectBody, string(body))
+			assert.Equal(t, tt.expectTrailer, resp.Trailer.Get("X-Export-Status"))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
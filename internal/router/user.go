@@ -3,16 +3,18 @@ package router
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
-	"github.com/officiallysidsingh/ecom-server/internal/config"
 	"github.com/officiallysidsingh/ecom-server/internal/handlers"
+	"github.com/officiallysidsingh/ecom-server/internal/middlewares"
 	"github.com/officiallysidsingh/ecom-server/internal/services"
 	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
 )
 
-func userRoutes(db *sqlx.DB, envConfig *config.EnvConfig) chi.Router {
+func userRoutes(db *sqlx.DB, keys *utils.KeySet) chi.Router {
 	// Initialize dependencies
 	userStore := store.NewUserStore(db)
-	userService := services.NewUserService(userStore, envConfig)
+	tokenStore := store.NewTokenStore(db)
+	userService := services.NewUserService(userStore, tokenStore, keys)
 	userHandler := handlers.NewUserHandler(userService)
 
 	// Setup a new router
@@ -21,6 +23,15 @@ func userRoutes(db *sqlx.DB, envConfig *config.EnvConfig) chi.Router {
 	// Routes
 	r.Post("/login", userHandler.Login)
 	r.Post("/signup", userHandler.Signup)
+	r.Post("/refresh", userHandler.Refresh)
+
+	// Authenticated Routes
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.ValidateJWT(db, keys))
+
+		r.Post("/logout", userHandler.Logout)
+		r.Post("/logout-all", userHandler.LogoutAll)
+	})
 
 	return r
 }
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+)
+
+var (
+	ErrEmptyCart        = errors.New("cart is empty")
+	ErrCartItemNotFound = store.ErrCartItemNotFound
+	ErrCartChanged      = store.ErrCartChanged
+)
+
+type CartService interface {
+	Get(ctx context.Context, currency string) (*models.Cart, error)
+	AddItem(ctx context.Context, itemReq *models.AddCartItemRequest) error
+	UpdateItem(ctx context.Context, variantID string, quantity int) error
+	RemoveItem(ctx context.Context, variantID string) error
+	Clear(ctx context.Context) error
+	Checkout(ctx context.Context, checkoutReq *models.CheckoutRequest) (string, error)
+}
+
+type cartService struct {
+	store        store.CartStore
+	rateStore    store.ExchangeRateStore
+	orderService OrderService
+}
+
+func NewCartService(store store.CartStore, rateStore store.ExchangeRateStore, orderService OrderService) CartService {
+	return &cartService{
+		store:        store,
+		rateStore:    rateStore,
+		orderService: orderService,
+	}
+}
+
+func (s *cartService) Get(ctx context.Context, currency string) (*models.Cart, error) {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return nil, errors.New("user not found in context")
+	}
+
+	currency, err := models.NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.store.GetByUserFromDB(ctx, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Price the cart with current product prices, converted the same way checkout will
+	converter := newPriceConverter(s.rateStore, currency)
+	cart := models.Cart{
+		UserID:   user.UserID,
+		Currency: currency,
+		Items:    []models.CartItem{},
+	}
+	for _, item := range items {
+		if item.UnitPrice, err = converter.convert(ctx, item.UnitPrice, item.Currency); err != nil {
+			return nil, err
+		}
+		item.TotalPrice = item.UnitPrice.Mul(item.Quantity)
+		cart.TotalPrice += item.TotalPrice
+		cart.Items = append(cart.Items, item)
+	}
+
+	return &cart, nil
+}
+
+func (s *cartService) AddItem(ctx context.Context, itemReq *models.AddCartItemRequest) error {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return errors.New("user not found in context")
+	}
+
+	if itemReq.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	return s.store.AddItemInDB(ctx, user.UserID, itemReq.ProductID, strings.TrimSpace(itemReq.VariantID), itemReq.Quantity)
+}
+
+func (s *cartService) UpdateItem(ctx context.Context, variantID string, quantity int) error {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return errors.New("user not found in context")
+	}
+
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	return s.store.UpdateItemInDB(ctx, user.UserID, variantID, quantity)
+}
+
+func (s *cartService) RemoveItem(ctx context.Context, variantID string) error {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return errors.New("user not found in context")
+	}
+
+	return s.store.RemoveItemFromDB(ctx, user.UserID, variantID)
+}
+
+func (s *cartService) Clear(ctx context.Context) error {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return errors.New("user not found in context")
+	}
+
+	return s.store.ClearFromDB(ctx, user.UserID)
+}
+
+func (s *cartService) Checkout(ctx context.Context, checkoutReq *models.CheckoutRequest) (string, error) {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return "", errors.New("user not found in context")
+	}
+
+	items, err := s.store.GetByUserFromDB(ctx, user.UserID)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", ErrEmptyCart
+	}
+
+	// Order creation re-prices every line and re-checks stock under lock,
+	// so prices shown in the cart are never trusted here
+	orderReq := models.CreateOrderRequest{
+		PaymentMethod: checkoutReq.PaymentMethod,
+		Currency:      checkoutReq.Currency,
+		Country:       checkoutReq.Country,
+		Region:        checkoutReq.Region,
+	}
+	for _, item := range items {
+		orderReq.Items = append(orderReq.Items, models.CreateOrderItemRequest{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	// Only the lines read above leave the cart, and only together with the order
+	return s.orderService.CreateFromCart(ctx, &orderReq, items)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+)
+
+var (
+	ErrMissingCategoryName = errors.New("category name is required")
+
+	ErrCategoryNotFound       = store.ErrCategoryNotFound
+	ErrParentCategoryNotFound = store.ErrParentCategoryNotFound
+	ErrCategoryCycle          = store.ErrCategoryCycle
+	ErrCategoryHasChildren    = store.ErrCategoryHasChildren
+)
+
+type CategoryService interface {
+	GetTree(ctx context.Context) ([]*models.CategoryNode, error)
+	GetByID(ctx context.Context, categoryID string) (*models.Category, error)
+	GetProducts(ctx context.Context, categoryID string, query models.ProductQuery) (*models.ProductPage, error)
+	Create(ctx context.Context, categoryReq *models.CategoryRequest) (string, error)
+	Update(ctx context.Context, categoryReq *models.CategoryRequest, categoryID string) error
+	Delete(ctx context.Context, categoryID string) error
+	AddProduct(ctx context.Context, categoryID string, productID string) error
+	RemoveProduct(ctx context.Context, categoryID string, productID string) error
+}
+
+type categoryService struct {
+	store          store.CategoryStore
+	productService ProductService
+}
+
+func NewCategoryService(store store.CategoryStore, productService ProductService) CategoryService {
+	return &categoryService{
+		store:          store,
+		productService: productService,
+	}
+}
+
+func (s *categoryService) GetTree(ctx context.Context) ([]*models.CategoryNode, error) {
+	nodes, err := s.store.GetTreeFromDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows come parents first, so every parent is indexed before its children
+	roots := []*models.CategoryNode{}
+	byID := make(map[string]*models.CategoryNode, len(nodes))
+	for _, node := range nodes {
+		node.Children = []*models.CategoryNode{}
+		byID[node.CategoryID] = node
+
+		if node.ParentID == nil {
+			roots = append(roots, node)
+		} else if parent, ok := byID[*node.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	return roots, nil
+}
+
+func (s *categoryService) GetByID(ctx context.Context, categoryID string) (*models.Category, error) {
+	return s.store.GetByIDFromDB(ctx, categoryID)
+}
+
+func (s *categoryService) GetProducts(ctx context.Context, categoryID string, query models.ProductQuery) (*models.ProductPage, error) {
+	if _, err := s.store.GetByIDFromDB(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	// Reuse the product listing, narrowed to the category subtree
+	query.CategoryID = categoryID
+	return s.productService.GetAll(ctx, query)
+}
+
+func (s *categoryService) Create(ctx context.Context, categoryReq *models.CategoryRequest) (string, error) {
+	category, err := newCategory(categoryReq)
+	if err != nil {
+		return "", err
+	}
+
+	return s.store.CreateInDB(ctx, category)
+}
+
+func (s *categoryService) Update(ctx context.Context, categoryReq *models.CategoryRequest, categoryID string) error {
+	category, err := newCategory(categoryReq)
+	if err != nil {
+		return err
+	}
+
+	return s.store.UpdateInDB(ctx, category, categoryID)
+}
+
+func (s *categoryService) Delete(ctx context.Context, categoryID string) error {
+	return s.store.DeleteFromDB(ctx, categoryID)
+}
+
+func (s *categoryService) AddProduct(ctx context.Context, categoryID string, productID string) error {
+	if _, err := s.store.GetByIDFromDB(ctx, categoryID); err != nil {
+		return err
+	}
+
+	return s.store.AddProductInDB(ctx, categoryID, productID)
+}
+
+func (s *categoryService) RemoveProduct(ctx context.Context, categoryID string, productID string) error {
+	return s.store.RemoveProductFromDB(ctx, categoryID, productID)
+}
+
+// Validate a category request and turn it into a category
+func newCategory(categoryReq *models.CategoryRequest) (*models.Category, error) {
+	name := strings.TrimSpace(categoryReq.Name)
+	if name == "" {
+		return nil, ErrMissingCategoryName
+	}
+
+	// An empty parent means a root category
+	parentID := categoryReq.ParentID
+	if parentID != nil && strings.TrimSpace(*parentID) == "" {
+		parentID = nil
+	}
+
+	return &models.Category{
+		ParentID:    parentID,
+		Name:        name,
+		Description: categoryReq.Description,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+)
+
+var (
+	ErrInvalidCurrency = models.ErrInvalidCurrency
+	ErrInvalidRate     = models.ErrInvalidRate
+
+	ErrExchangeRateNotFound = store.ErrExchangeRateNotFound
+)
+
+type ExchangeRateService interface {
+	GetAll(ctx context.Context) ([]models.ExchangeRate, error)
+	Set(ctx context.Context, baseCurrency string, quoteCurrency string, rateReq *models.ExchangeRateRequest) (*models.ExchangeRate, error)
+	Delete(ctx context.Context, baseCurrency string, quoteCurrency string) error
+}
+
+type exchangeRateService struct {
+	store store.ExchangeRateStore
+}
+
+func NewExchangeRateService(store store.ExchangeRateStore) ExchangeRateService {
+	return &exchangeRateService{
+		store: store,
+	}
+}
+
+func (s *exchangeRateService) GetAll(ctx context.Context) ([]models.ExchangeRate, error) {
+	rates, err := s.store.GetAllFromDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rates == nil {
+		rates = []models.ExchangeRate{}
+	}
+
+	return rates, nil
+}
+
+func (s *exchangeRateService) Set(ctx context.Context, baseCurrency string, quoteCurrency string, rateReq *models.ExchangeRateRequest) (*models.ExchangeRate, error) {
+	base, quote, err := currencyPair(baseCurrency, quoteCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if rateReq.Rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: rate must be greater than 0", ErrInvalidRate)
+	}
+
+	rate := models.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          rateReq.Rate,
+	}
+	if err := s.store.UpsertInDB(ctx, &rate); err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+func (s *exchangeRateService) Delete(ctx context.Context, baseCurrency string, quoteCurrency string) error {
+	base, quote, err := currencyPair(baseCurrency, quoteCurrency)
+	if err != nil {
+		return err
+	}
+
+	return s.store.DeleteFromDB(ctx, base, quote)
+}
+
+// Normalize both sides of a currency pair; a currency never needs a rate to itself
+func currencyPair(baseCurrency string, quoteCurrency string) (string, string, error) {
+	if baseCurrency == "" || quoteCurrency == "" {
+		return "", "", fmt.Errorf("%w: both currencies of the pair are required", ErrInvalidCurrency)
+	}
+	base, err := models.NormalizeCurrency(baseCurrency)
+	if err != nil {
+		return "", "", err
+	}
+	quote, err := models.NormalizeCurrency(quoteCurrency)
+	if err != nil {
+		return "", "", err
+	}
+	if base == quote {
+		return "", "", fmt.Errorf("%w: %s converts to itself at a rate of 1", ErrInvalidCurrency, base)
+	}
+	return base, quote, nil
+}
+
+// priceConverter converts prices into a single currency, looking up the rate of each source currency once
+type priceConverter struct {
+	rates  store.ExchangeRateStore
+	target string
+	cache  map[string]models.Rate
+}
+
+func newPriceConverter(rates store.ExchangeRateStore, target string) *priceConverter {
+	return &priceConverter{
+		rates:  rates,
+		target: target,
+		cache:  make(map[string]models.Rate),
+	}
+}
+
+// Convert an amount from a currency into the target currency, rounding to the cent with models.Money.MulRate
+func (c *priceConverter) convert(ctx context.Context, amount models.Money, from string) (models.Money, error) {
+	rate, ok := c.cache[from]
+	if !ok {
+		var err error
+		if rate, err = c.rates.GetRateFromDB(ctx, from, c.target); err != nil {
+			return 0, err
+		}
+		c.cache[from] = rate
+	}
+
+	return amount.MulRate(rate.Rat()), nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/officiallysidsingh/ecom-server/internal/models"
 	"github.com/officiallysidsingh/ecom-server/internal/store"
@@ -12,22 +13,51 @@ import (
 
 const userContextKey models.ContextKey = "user"
 
+var (
+	ErrMissingPaymentMethod = errors.New("payment method is required")
+	ErrMissingCountry       = errors.New("country is required to calculate tax")
+	ErrEmptyOrder           = errors.New("order must contain at least one item")
+	ErrInvalidQuantity      = errors.New("quantity must be greater than 0")
+	ErrProductNotFound      = store.ErrProductNotFound
+	ErrInsufficientStock    = store.ErrInsufficientStock
+	ErrOrderNotFound        = store.ErrOrderNotFound
+	ErrStatusConflict       = store.ErrStatusConflict
+	ErrInvalidTransition    = errors.New("invalid order status transition")
+)
+
+// Allowed moves of the order state machine, keyed by the current status
+var orderTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusPending:   {models.OrderStatusPaid, models.OrderStatusCancelled},
+	models.OrderStatusPaid:      {models.OrderStatusFulfilled, models.OrderStatusCancelled, models.OrderStatusRefunded},
+	models.OrderStatusFulfilled: {models.OrderStatusShipped, models.OrderStatusCancelled, models.OrderStatusRefunded},
+	models.OrderStatusShipped:   {models.OrderStatusDelivered},
+	models.OrderStatusDelivered: {models.OrderStatusRefunded},
+	models.OrderStatusCancelled: {},
+	models.OrderStatusRefunded:  {},
+}
+
 type OrderService interface {
 	GetAll(ctx context.Context) ([]models.Order, error)
 	GetByID(ctx context.Context, orderID string) (*models.Order, error)
-	// Create(ctx context.Context, order *models.Order) (string, error)
+	Create(ctx context.Context, orderReq *models.CreateOrderRequest) (string, error)
+	CreateFromCart(ctx context.Context, orderReq *models.CreateOrderRequest, cartItems []models.CartItem) (string, error)
+	Transition(ctx context.Context, orderID string, to models.OrderStatus) error
+	GetStatusHistory(ctx context.Context, orderID string) ([]models.OrderStatusChange, error)
+	Cancel(ctx context.Context, orderID string) error
 	// PutUpdate(ctx context.Context, order *models.Order, orderID string) error
 	// PatchUpdate(ctx context.Context, order *models.Order, orderID string) error
 	// Delete(ctx context.Context, orderID string) error
 }
 
 type orderService struct {
-	store store.OrderStore
+	store      store.OrderStore
+	taxService TaxService
 }
 
-func NewOrderService(store store.OrderStore) OrderService {
+func NewOrderService(store store.OrderStore, taxService TaxService) OrderService {
 	return &orderService{
-		store: store,
+		store:      store,
+		taxService: taxService,
 	}
 }
 
@@ -54,8 +84,175 @@ func (s *orderService) GetByID(ctx context.Context, orderID string) (*models.Ord
 		return nil, fmt.Errorf("user not found in context")
 	}
 
-	// Extract user_id from Claims
-	userID := user.UserID
+	return s.getReadable(ctx, user, orderID)
+}
+
+func (s *orderService) Create(ctx context.Context, orderReq *models.CreateOrderRequest) (string, error) {
+	return s.create(ctx, orderReq, nil)
+}
+
+// CreateFromCart places the order and removes cartItems from the cart in the same transaction
+func (s *orderService) CreateFromCart(ctx context.Context, orderReq *models.CreateOrderRequest, cartItems []models.CartItem) (string, error) {
+	return s.create(ctx, orderReq, cartItems)
+}
+
+func (s *orderService) create(ctx context.Context, orderReq *models.CreateOrderRequest, cartItems []models.CartItem) (string, error) {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return "", errors.New("user not found in context")
+	}
+
+	if strings.TrimSpace(orderReq.PaymentMethod) == "" {
+		return "", ErrMissingPaymentMethod
+	}
+	if len(orderReq.Items) == 0 {
+		return "", ErrEmptyOrder
+	}
+	currency, err := models.NormalizeCurrency(orderReq.Currency)
+	if err != nil {
+		return "", err
+	}
+	// Tax depends on where the order ships, so there is no safe default
+	if strings.TrimSpace(orderReq.Country) == "" {
+		return "", ErrMissingCountry
+	}
+	country, err := models.NormalizeCountry(orderReq.Country)
+	if err != nil {
+		return "", err
+	}
+	region, err := models.NormalizeRegion(orderReq.Region)
+	if err != nil {
+		return "", err
+	}
+
+	// Merge repeated product variants into a single line
+	type lineKey struct{ productID, variantID string }
+	quantities := make(map[lineKey]int)
+	var keys []lineKey
+	for _, item := range orderReq.Items {
+		if item.Quantity <= 0 {
+			return "", ErrInvalidQuantity
+		}
+		key := lineKey{item.ProductID, strings.TrimSpace(item.VariantID)}
+		if _, exists := quantities[key]; !exists {
+			keys = append(keys, key)
+		}
+		quantities[key] += item.Quantity
+	}
+
+	// Prices are filled in by the store from the locked variant rows
+	order := models.Order{
+		UserID:        user.UserID,
+		PaymentMethod: orderReq.PaymentMethod,
+		Currency:      currency,
+		TaxCountry:    &country,
+		TaxRegion:     region,
+	}
+	for _, key := range keys {
+		item := models.OrderItem{
+			ProductID: key.productID,
+			Quantity:  quantities[key],
+		}
+		if key.variantID != "" {
+			variantID := key.variantID
+			item.VariantID = &variantID
+		}
+		order.Items = append(order.Items, item)
+	}
+
+	// The order records the rule versions it was taxed with, so later rule changes never alter it
+	taxRules, err := s.taxService.RulesFor(ctx, country, region)
+	if err != nil {
+		return "", err
+	}
+
+	return s.store.CreateInDB(ctx, &order, taxRules, cartItems)
+}
+
+func (s *orderService) Transition(ctx context.Context, orderID string, to models.OrderStatus) error {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return errors.New("user not found in context")
+	}
+
+	// Cancelling has to give stock back, so it only goes through Cancel
+	if to == models.OrderStatusCancelled {
+		return fmt.Errorf("%w: orders are cancelled through the cancel endpoint", ErrInvalidTransition)
+	}
+
+	from, err := s.store.GetStatusFromDB(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	if err := validateTransition(from, to); err != nil {
+		return err
+	}
+
+	return s.store.UpdateStatusInDB(ctx, orderID, from, to, user.UserID)
+}
+
+func (s *orderService) GetStatusHistory(ctx context.Context, orderID string) ([]models.OrderStatusChange, error) {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return nil, errors.New("user not found in context")
+	}
+
+	// Customers may only see the history of their own orders
+	if _, err := s.getReadable(ctx, user, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.store.GetStatusHistoryFromDB(ctx, orderID)
+}
+
+func (s *orderService) Cancel(ctx context.Context, orderID string) error {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return errors.New("user not found in context")
+	}
+
+	// Users can only cancel their own orders
+	order, err := s.store.GetByIDFromDB(ctx, orderID, user.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Shipped orders can no longer be cancelled
+	if err := validateTransition(order.Status, models.OrderStatusCancelled); err != nil {
+		return err
+	}
+
+	return s.store.CancelInDB(ctx, orderID, user.UserID, order.Status)
+}
+
+// Staff who can read any order are not scoped to their own user ID
+func (s *orderService) getReadable(ctx context.Context, user models.Claims, orderID string) (*models.Order, error) {
+	if user.HasPermission(models.PermissionOrdersReadAny) {
+		return s.store.GetAnyByIDFromDB(ctx, orderID)
+	}
+	return s.store.GetByIDFromDB(ctx, orderID, user.UserID)
+}
+
+// Check a status change against the order state machine
+func validateTransition(from models.OrderStatus, to models.OrderStatus) error {
+	if _, known := orderTransitions[to]; !known {
+		return fmt.Errorf("%w: unknown status %s", ErrInvalidTransition, to)
+	}
+
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
 
-	return s.store.GetByIDFromDB(ctx, orderID, userID)
+	return fmt.Errorf("%w: cannot move order from %s to %s", ErrInvalidTransition, from, to)
 }
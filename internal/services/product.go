@@ -1,109 +1,542 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
 
 	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/storage"
 	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/workers"
 )
 
 var (
 	ErrInvalidPrice = errors.New("price must be greater than 0")
 	ErrInvalidStock = errors.New("stock cannot be negative")
+
+	ErrMissingProductName  = errors.New("product name cannot be empty")
+	ErrInvalidProductPatch = store.ErrInvalidProductPatch
+
+	ErrMissingSearchQuery = errors.New("search query is required")
+	ErrMissingSKU         = errors.New("sku is required")
+
+	ErrImageTooLarge        = errors.New("image is too large")
+	ErrUnsupportedImageType = errors.New("image must be a JPEG, PNG, GIF or WebP file")
+
+	ErrVariantNotFound     = store.ErrVariantNotFound
+	ErrSKUExists           = store.ErrSKUExists
+	ErrDefaultVariantFixed = store.ErrDefaultVariantFixed
+
+	ErrInvalidProductQuery = store.ErrInvalidProductQuery
+	ErrInvalidCursor       = store.ErrInvalidCursor
+
+	ErrProductVersionMismatch = store.ErrProductVersionMismatch
+	ErrStockSetOnVariants     = store.ErrStockSetOnVariants
+)
+
+const (
+	defaultProductPageSize = 20
+	maxProductPageSize     = 100
 )
 
+// MaxImageSize is the largest product image accepted, in bytes
+const MaxImageSize = 5 << 20
+
+// File extensions of the accepted image types, keyed by sniffed content type
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
 type ProductService interface {
-	GetAll(ctx context.Context) ([]models.Product, error)
-	GetByID(ctx context.Context, productID string) (*models.Product, error)
+	GetAll(ctx context.Context, query models.ProductQuery) (*models.ProductPage, error)
+	Search(ctx context.Context, query models.ProductQuery) (*models.ProductSearchPage, error)
+	// GetByID converts prices into currency unless it is empty
+	GetByID(ctx context.Context, productID string, currency string) (*models.Product, error)
 	Create(ctx context.Context, product *models.Product) (string, error)
-	PutUpdate(ctx context.Context, product *models.Product, productID string) error
-	PatchUpdate(ctx context.Context, product *models.Product, productID string) error
-	Delete(ctx context.Context, productID string) error
+	// PutUpdate, PatchUpdate and Delete fail with ErrProductVersionMismatch unless the product is still at version
+	PutUpdate(ctx context.Context, product *models.Product, productID string, version int) error
+	// PatchUpdate only writes the fields present in the patch and returns the new version
+	PatchUpdate(ctx context.Context, patch *models.ProductPatch, productID string, version int) (int, error)
+	Delete(ctx context.Context, productID string, version int) error
+	Restore(ctx context.Context, productID string) error
+	CreateVariant(ctx context.Context, productID string, variantReq *models.VariantRequest) (string, error)
+	UpdateVariant(ctx context.Context, productID string, variantID string, variantReq *models.VariantRequest) error
+	DeleteVariant(ctx context.Context, productID string, variantID string) error
+	UploadImage(ctx context.Context, productID string, file io.Reader, size int64) (*models.ProductImage, error)
+	Import(ctx context.Context, format string, body io.Reader, dryRun bool) (*models.ImportReport, error)
+	// Export calls fn for every product matching the filters, in sort order, without loading them all
+	Export(ctx context.Context, query models.ProductQuery, fn func(*models.Product) error) error
 }
 
 type productService struct {
-	store store.ProductStore
+	store        store.ProductStore
+	variantStore store.VariantStore
+	imageStore   store.ImageStore
+	rateStore    store.ExchangeRateStore
+	files        storage.Backend
+	thumbnails   workers.ThumbnailQueue
 }
 
-func NewProductService(store store.ProductStore) ProductService {
+func NewProductService(
+	store store.ProductStore,
+	variantStore store.VariantStore,
+	imageStore store.ImageStore,
+	rateStore store.ExchangeRateStore,
+	files storage.Backend,
+	thumbnails workers.ThumbnailQueue,
+) ProductService {
 	return &productService{
-		store: store,
+		store:        store,
+		variantStore: variantStore,
+		imageStore:   imageStore,
+		rateStore:    rateStore,
+		files:        files,
+		thumbnails:   thumbnails,
 	}
 }
 
-func (s *productService) GetAll(ctx context.Context) ([]models.Product, error) {
-	return s.store.GetAllFromDB(ctx)
+func (s *productService) GetAll(ctx context.Context, query models.ProductQuery) (*models.ProductPage, error) {
+	if err := normalizeProductSort(&query); err != nil {
+		return nil, err
+	}
+	if err := normalizeProductPaging(&query); err != nil {
+		return nil, err
+	}
+	if err := validateProductFilters(query); err != nil {
+		return nil, err
+	}
+	if err := normalizeProductCurrency(&query); err != nil {
+		return nil, err
+	}
+
+	page, err := s.store.GetAllFromDB(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Show every price in the requested currency
+	if query.Currency != "" {
+		converter := newPriceConverter(s.rateStore, query.Currency)
+		for i := range page.Items {
+			if err := convertProductPrices(ctx, converter, &page.Items[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return page, nil
 }
 
-func (s *productService) GetByID(ctx context.Context, productID string) (*models.Product, error) {
-	return s.store.GetByIDFromDB(ctx, productID)
+func (s *productService) Search(ctx context.Context, query models.ProductQuery) (*models.ProductSearchPage, error) {
+	query.Search = strings.TrimSpace(query.Search)
+	if query.Search == "" {
+		return nil, ErrMissingSearchQuery
+	}
+
+	// Results are always ordered by relevance
+	query.Sort = ""
+	query.Order = ""
+
+	if err := normalizeProductPaging(&query); err != nil {
+		return nil, err
+	}
+	if err := validateProductFilters(query); err != nil {
+		return nil, err
+	}
+	if err := normalizeProductCurrency(&query); err != nil {
+		return nil, err
+	}
+
+	page, err := s.store.SearchFromDB(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Show every price in the requested currency
+	if query.Currency != "" {
+		converter := newPriceConverter(s.rateStore, query.Currency)
+		for i := range page.Items {
+			if err := convertProductPrices(ctx, converter, &page.Items[i].Product); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return page, nil
 }
 
-func (s *productService) Create(ctx context.Context, product *models.Product) (string, error) {
-	if product.Price <= 0 {
-		return "", ErrInvalidPrice
+func (s *productService) GetByID(ctx context.Context, productID string, currency string) (*models.Product, error) {
+	if currency != "" {
+		var err error
+		if currency, err = models.NormalizeCurrency(currency); err != nil {
+			return nil, err
+		}
 	}
-	if product.Stock < 0 {
-		return "", ErrInvalidStock
+
+	product, err := s.store.GetByIDFromDB(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nest the sellable variants under the product
+	variants, err := s.variantStore.GetByProductFromDB(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	product.Variants = variants
+
+	// Images carry their public URL from the storage backend
+	images, err := s.imageStore.GetByProductFromDB(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	thumbnails, err := s.imageStore.GetThumbnailsByProductFromDB(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	byImage := make(map[string][]models.ImageThumbnail)
+	for _, thumbnail := range thumbnails {
+		thumbnail.URL = s.files.URL(thumbnail.StorageKey)
+		byImage[thumbnail.ImageID] = append(byImage[thumbnail.ImageID], thumbnail)
+	}
+	for i := range images {
+		images[i].URL = s.files.URL(images[i].StorageKey)
+		images[i].Thumbnails = byImage[images[i].ImageID]
+		if images[i].Thumbnails == nil {
+			images[i].Thumbnails = []models.ImageThumbnail{}
+		}
+	}
+	product.Images = images
+
+	// Show every price in the requested currency
+	if currency != "" {
+		if err := convertProductPrices(ctx, newPriceConverter(s.rateStore, currency), product); err != nil {
+			return nil, err
+		}
+	}
+
+	return product, nil
+}
+
+func (s *productService) Create(ctx context.Context, product *models.Product) (string, error) {
+	if err := validateNewProduct(product); err != nil {
+		return "", err
 	}
 
 	return s.store.CreateInDB(ctx, product)
 }
 
-func (s *productService) PutUpdate(ctx context.Context, product *models.Product, productID string) error {
-	if product.Price <= 0 {
-		return ErrInvalidPrice
+func (s *productService) PutUpdate(ctx context.Context, product *models.Product, productID string, version int) error {
+	// A full update replaces the currency too, falling back to the default
+	if err := validateNewProduct(product); err != nil {
+		return err
 	}
-	if product.Stock < 0 {
-		return ErrInvalidStock
+
+	if err := s.checkVersion(ctx, productID, version); err != nil {
+		return err
 	}
 
-	_, err := s.store.GetByIDFromDB(ctx, productID)
+	err := s.store.PutUpdateInDB(ctx, product, productID, version)
 	if err != nil {
 		return err
 	}
 
-	err = s.store.PutUpdateInDB(ctx, product, productID)
+	return nil
+}
+
+func (s *productService) PatchUpdate(ctx context.Context, patch *models.ProductPatch, productID string, version int) (int, error) {
+	if err := validateProductPatch(patch); err != nil {
+		return 0, err
+	}
+
+	if err := s.checkVersion(ctx, productID, version); err != nil {
+		return 0, err
+	}
+
+	return s.store.PatchUpdateInDB(ctx, patch, productID, version)
+}
+
+func (s *productService) Delete(ctx context.Context, productID string, version int) error {
+	if err := s.checkVersion(ctx, productID, version); err != nil {
+		return err
+	}
+
+	err := s.store.DeleteFromDB(ctx, productID, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete product with ID %s: %w", productID, err)
+	}
+
+	return nil
+}
+
+// Fail early when the product is missing or has moved past the version the client read;
+// the store re-checks the version in the write itself
+func (s *productService) checkVersion(ctx context.Context, productID string, version int) error {
+	product, err := s.store.GetByIDFromDB(ctx, productID)
 	if err != nil {
 		return err
 	}
+	if product.Version != version {
+		return fmt.Errorf("%w: product with ID %s is at version %d", ErrProductVersionMismatch, productID, product.Version)
+	}
 
 	return nil
 }
 
-func (s *productService) PatchUpdate(ctx context.Context, product *models.Product, productID string) error {
-	if product.Price != 0 && product.Price <= 0 {
+func (s *productService) Restore(ctx context.Context, productID string) error {
+	return s.store.RestoreInDB(ctx, productID)
+}
+
+func (s *productService) CreateVariant(ctx context.Context, productID string, variantReq *models.VariantRequest) (string, error) {
+	variant, err := newVariant(productID, variantReq)
+	if err != nil {
+		return "", err
+	}
+
+	return s.variantStore.CreateInDB(ctx, variant)
+}
+
+func (s *productService) UpdateVariant(ctx context.Context, productID string, variantID string, variantReq *models.VariantRequest) error {
+	variant, err := newVariant(productID, variantReq)
+	if err != nil {
+		return err
+	}
+
+	return s.variantStore.UpdateInDB(ctx, variant, productID, variantID)
+}
+
+func (s *productService) DeleteVariant(ctx context.Context, productID string, variantID string) error {
+	return s.variantStore.DeleteFromDB(ctx, productID, variantID)
+}
+
+func (s *productService) UploadImage(ctx context.Context, productID string, file io.Reader, size int64) (*models.ProductImage, error) {
+	if size > MaxImageSize {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrImageTooLarge, MaxImageSize)
+	}
+
+	// Trust the file content rather than the type the client claims
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrUnsupportedImageType
+		}
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: got %s", ErrUnsupportedImageType, contentType)
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, fmt.Errorf("failed to name image: %w", err)
+	}
+
+	image := models.ProductImage{
+		ProductID:   productID,
+		StorageKey:  fmt.Sprintf("products/%s/%s%s", productID, hex.EncodeToString(name), ext),
+		ContentType: contentType,
+		SizeBytes:   size,
+	}
+
+	body := io.MultiReader(bytes.NewReader(head[:n]), file)
+	if err := s.files.Put(ctx, image.StorageKey, body, contentType); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.imageStore.CreateInDB(ctx, &image); err != nil {
+		// Don't leave a file behind that no product points at
+		if deleteErr := s.files.Delete(ctx, image.StorageKey); deleteErr != nil {
+			log.Printf("Error removing orphaned image %s: %v", image.StorageKey, deleteErr)
+		}
+		return nil, err
+	}
+	image.URL = s.files.URL(image.StorageKey)
+	image.Thumbnails = []models.ImageThumbnail{}
+
+	// Thumbnails show up on the product once the worker has made them
+	s.thumbnails.Enqueue(image)
+
+	return &image, nil
+}
+
+// Rules every new product has to pass, whether created one by one or imported
+func validateNewProduct(product *models.Product) error {
+	if product.Price <= 0 {
 		return ErrInvalidPrice
 	}
 	if product.Stock < 0 {
 		return ErrInvalidStock
 	}
 
-	_, err := s.store.GetByIDFromDB(ctx, productID)
+	// Prices are in the default currency unless the product names another
+	currency, err := models.NormalizeCurrency(product.Currency)
 	if err != nil {
 		return err
 	}
+	product.Currency = currency
 
-	err = s.store.PatchUpdateInDB(ctx, product, productID)
+	// Products are taxed at the standard rate unless they name another tax class
+	taxClass, err := models.NormalizeTaxClass(product.TaxClass)
 	if err != nil {
 		return err
 	}
+	product.TaxClass = taxClass
 
 	return nil
 }
 
-func (s *productService) Delete(ctx context.Context, productID string) error {
-	_, err := s.store.GetByIDFromDB(ctx, productID)
+// Validate each field present in a product patch; absent fields are left as they are
+func validateProductPatch(patch *models.ProductPatch) error {
+	if patch.Name == nil && patch.Description == nil && patch.Price == nil && patch.Currency == nil && patch.TaxClass == nil && patch.Stock == nil {
+		return fmt.Errorf("%w: patch sets no fields", ErrInvalidProductPatch)
+	}
+	if patch.Name != nil && strings.TrimSpace(*patch.Name) == "" {
+		return ErrMissingProductName
+	}
+	if patch.Price != nil && *patch.Price <= 0 {
+		return ErrInvalidPrice
+	}
+	if patch.Stock != nil && *patch.Stock < 0 {
+		return ErrInvalidStock
+	}
+	if patch.Currency != nil {
+		currency, err := models.NormalizeCurrency(*patch.Currency)
+		if err != nil {
+			return err
+		}
+		patch.Currency = &currency
+	}
+	if patch.TaxClass != nil {
+		taxClass, err := models.NormalizeTaxClass(*patch.TaxClass)
+		if err != nil {
+			return err
+		}
+		patch.TaxClass = &taxClass
+	}
+	return nil
+}
+
+// Validate a variant request and turn it into a variant of the product
+func newVariant(productID string, variantReq *models.VariantRequest) (*models.ProductVariant, error) {
+	sku := strings.TrimSpace(variantReq.SKU)
+	if sku == "" {
+		return nil, ErrMissingSKU
+	}
+	if variantReq.Price != nil && *variantReq.Price <= 0 {
+		return nil, ErrInvalidPrice
+	}
+	if variantReq.Stock < 0 {
+		return nil, ErrInvalidStock
+	}
+
+	options := variantReq.Options
+	if options == nil {
+		options = models.VariantOptions{}
+	}
+
+	return &models.ProductVariant{
+		ProductID: productID,
+		SKU:       sku,
+		Options:   options,
+		Price:     variantReq.Price,
+		Stock:     variantReq.Stock,
+	}, nil
+}
+
+// Fill in the default sort and validate it against the allow-list
+func normalizeProductSort(query *models.ProductQuery) error {
+	if query.Sort == "" {
+		query.Sort = models.ProductSortCreatedAt
+	}
+	if query.Order == "" {
+		query.Order = models.SortDesc
+	}
+
+	switch query.Sort {
+	case models.ProductSortPrice, models.ProductSortCreatedAt, models.ProductSortName:
+	default:
+		return fmt.Errorf("%w: sort must be one of price, created_at, name", ErrInvalidProductQuery)
+	}
+	if query.Order != models.SortAsc && query.Order != models.SortDesc {
+		return fmt.Errorf("%w: order must be asc or desc", ErrInvalidProductQuery)
+	}
+
+	return nil
+}
+
+// Apply the default page size and validate limit, offset and cursor
+func normalizeProductPaging(query *models.ProductQuery) error {
+	if query.Limit == 0 {
+		query.Limit = defaultProductPageSize
+	}
+	if query.Limit < 0 || query.Limit > maxProductPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidProductQuery, maxProductPageSize)
+	}
+	if query.Offset < 0 {
+		return fmt.Errorf("%w: offset cannot be negative", ErrInvalidProductQuery)
+	}
+	if query.Cursor != "" && query.Offset > 0 {
+		return fmt.Errorf("%w: use either cursor or offset, not both", ErrInvalidProductQuery)
+	}
+	return nil
+}
+
+// Check the currency prices are converted into; filters and sorting still use each product's own prices
+func normalizeProductCurrency(query *models.ProductQuery) error {
+	if query.Currency == "" {
+		return nil
+	}
+	currency, err := models.NormalizeCurrency(query.Currency)
 	if err != nil {
 		return err
 	}
+	query.Currency = currency
+	return nil
+}
 
-	err = s.store.DeleteFromDB(ctx, productID)
-	if err != nil {
-		return fmt.Errorf("failed to delete product with ID %s: %v", productID, err)
+// Convert the product and variant prices into the converter's currency
+func convertProductPrices(ctx context.Context, converter *priceConverter, product *models.Product) error {
+	from := product.Currency
+
+	var err error
+	if product.Price, err = converter.convert(ctx, product.Price, from); err != nil {
+		return err
+	}
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+		if variant.Price != nil {
+			price, err := converter.convert(ctx, *variant.Price, from)
+			if err != nil {
+				return err
+			}
+			variant.Price = &price
+		}
+		if variant.EffectivePrice, err = converter.convert(ctx, variant.EffectivePrice, from); err != nil {
+			return err
+		}
 	}
+	product.Currency = converter.target
 
 	return nil
 }
+
+func validateProductFilters(query models.ProductQuery) error {
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		return fmt.Errorf("%w: min_price cannot be greater than max_price", ErrInvalidProductQuery)
+	}
+	return nil
+}
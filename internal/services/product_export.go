@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+)
+
+func (s *productService) Export(ctx context.Context, query models.ProductQuery, fn func(*models.Product) error) error {
+	// Exports always cover every matching product
+	query.Limit, query.Offset, query.Cursor = 0, 0, ""
+
+	if err := normalizeProductSort(&query); err != nil {
+		return err
+	}
+	if err := validateProductFilters(query); err != nil {
+		return err
+	}
+	if err := normalizeProductCurrency(&query); err != nil {
+		return err
+	}
+	if query.Currency == "" {
+		return s.store.ExportFromDB(ctx, query, fn)
+	}
+
+	// Show every price in the requested currency
+	converter := newPriceConverter(s.rateStore, query.Currency)
+	return s.store.ExportFromDB(ctx, query, func(product *models.Product) error {
+		if err := convertProductPrices(ctx, converter, product); err != nil {
+			return err
+		}
+		return fn(product)
+	})
+}
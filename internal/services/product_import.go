@@ -0,0 +1,289 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+)
+
+var (
+	ErrInvalidImport     = errors.New("invalid import file")
+	ErrMissingExternalID = errors.New("external_id is required")
+)
+
+// Rows written per transaction
+const importBatchSize = 500
+
+// Longest JSON Lines record accepted
+const maxImportLineSize = 1 << 20
+
+// CSV columns, in any order; the rest are optional
+var (
+	requiredImportColumns = []string{"external_id", "name", "price"}
+	optionalImportColumns = []string{"description", "currency", "tax_class", "stock"}
+)
+
+func (s *productService) Import(ctx context.Context, format string, body io.Reader, dryRun bool) (*models.ImportReport, error) {
+	var rows []models.ProductImportRow
+	var failed []models.ImportRowResult
+	var err error
+
+	switch format {
+	case models.ImportFormatCSV:
+		rows, failed, err = parseImportCSV(body)
+	case models.ImportFormatJSONL:
+		rows, failed, err = parseImportJSONL(body)
+	default:
+		return nil, fmt.Errorf("%w: format must be csv or jsonl", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate with the same rules as a single create, and reject repeated keys
+	seen := make(map[string]int)
+	valid := make([]models.ProductImportRow, 0, len(rows))
+	for _, row := range rows {
+		row.ExternalID = strings.TrimSpace(row.ExternalID)
+
+		var err error
+		if row.ExternalID == "" {
+			err = ErrMissingExternalID
+		} else if firstLine, repeated := seen[row.ExternalID]; repeated {
+			err = fmt.Errorf("external_id %s already used on line %d", row.ExternalID, firstLine)
+		} else {
+			product := models.Product{Name: row.Name, Price: row.Price, Currency: row.Currency, TaxClass: row.TaxClass, Stock: row.Stock}
+			if err = validateNewProduct(&product); err == nil {
+				row.Currency, row.TaxClass = product.Currency, product.TaxClass
+			}
+		}
+		if err != nil {
+			failed = append(failed, failedImportRow(row, err))
+			continue
+		}
+		seen[row.ExternalID] = row.Line
+		valid = append(valid, row)
+	}
+
+	results := failed
+	for start := 0; start < len(valid); start += importBatchSize {
+		batch := valid[start:min(start+importBatchSize, len(valid))]
+
+		var batchResults []models.ImportRowResult
+		if dryRun {
+			batchResults, err = s.planImportBatch(ctx, batch)
+		} else {
+			batchResults, err = s.store.ImportBatchInDB(ctx, batch)
+		}
+		if err != nil {
+			// The batch was rolled back as a whole, so every row in it failed
+			for _, row := range batch {
+				results = append(results, failedImportRow(row, err))
+			}
+			continue
+		}
+		results = append(results, batchResults...)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Line < results[j].Line
+	})
+
+	report := models.ImportReport{
+		DryRun: dryRun,
+		Total:  len(results),
+		Rows:   results,
+	}
+	for _, result := range results {
+		switch result.Action {
+		case models.ImportActionCreated:
+			report.Created++
+		case models.ImportActionUpdated:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+	}
+
+	return &report, nil
+}
+
+// Work out what a batch would do without writing it
+func (s *productService) planImportBatch(ctx context.Context, batch []models.ProductImportRow) ([]models.ImportRowResult, error) {
+	externalIDs := make([]string, 0, len(batch))
+	for _, row := range batch {
+		externalIDs = append(externalIDs, row.ExternalID)
+	}
+
+	targets, err := s.store.GetExistingExternalIDsFromDB(ctx, externalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.ImportRowResult, 0, len(batch))
+	for _, row := range batch {
+		// Archived products are never overwritten by an import
+		target, exists := targets[row.ExternalID]
+		if target.Archived {
+			results = append(results, failedImportRow(row, fmt.Errorf("%w: product with external ID %s is archived", ErrProductNotFound, row.ExternalID)))
+			continue
+		}
+		// Stock of products with variants is only set per variant
+		if target.HasVariants && row.Stock != target.Stock {
+			results = append(results, failedImportRow(row, fmt.Errorf("%w: product with external ID %s has variants, so only its current total stock can be imported", ErrStockSetOnVariants, row.ExternalID)))
+			continue
+		}
+		action := models.ImportActionCreated
+		if exists {
+			action = models.ImportActionUpdated
+		}
+		results = append(results, models.ImportRowResult{
+			Line:       row.Line,
+			ExternalID: row.ExternalID,
+			Action:     action,
+		})
+	}
+
+	return results, nil
+}
+
+// Parse a CSV file with a header row, collecting rows that can't be read as failures
+func parseImportCSV(body io.Reader) ([]models.ProductImportRow, []models.ImportRowResult, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("%w: file is empty", ErrInvalidImport)
+		}
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(requiredImportColumns, name) && !slices.Contains(optionalImportColumns, name) {
+			return nil, nil, fmt.Errorf("%w: unknown column %q", ErrInvalidImport, name)
+		}
+		if _, repeated := columns[name]; repeated {
+			return nil, nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidImport, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("%w: missing column %q", ErrInvalidImport, name)
+		}
+	}
+
+	var rows []models.ProductImportRow
+	var failed []models.ImportRowResult
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// A malformed, short or long row is a problem with that row only. FieldPos is only
+		// valid after a successful read, so the line of a bad row comes from the parse error
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+			}
+			message := parseErr.Err.Error()
+			if errors.Is(err, csv.ErrFieldCount) {
+				message = "wrong number of columns"
+			}
+			failed = append(failed, models.ImportRowResult{Line: parseErr.StartLine, Action: models.ImportActionFailed, Error: message})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := models.ProductImportRow{
+			Line:        line,
+			ExternalID:  field("external_id"),
+			Name:        field("name"),
+			Description: field("description"),
+			Currency:    field("currency"),
+			TaxClass:    field("tax_class"),
+		}
+		if row.Price, err = models.ParseMoney(field("price")); err != nil {
+			failed = append(failed, failedImportRow(row, fmt.Errorf("price must be a number with at most 2 decimal places")))
+			continue
+		}
+		if stock := field("stock"); stock != "" {
+			if row.Stock, err = strconv.Atoi(stock); err != nil {
+				failed = append(failed, failedImportRow(row, fmt.Errorf("stock must be a whole number")))
+				continue
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, failed, nil
+}
+
+// Parse one JSON object per line, collecting lines that can't be decoded as failures
+func parseImportJSONL(body io.Reader) ([]models.ProductImportRow, []models.ImportRowResult, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+
+	var rows []models.ProductImportRow
+	var failed []models.ImportRowResult
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+
+		row := models.ProductImportRow{Line: line}
+		if err := decoder.Decode(&row); err != nil {
+			failed = append(failed, failedImportRow(row, fmt.Errorf("invalid JSON: %v", err)))
+			continue
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, nil, fmt.Errorf("%w: line %d is longer than %d bytes", ErrInvalidImport, line+1, maxImportLineSize)
+		}
+		return nil, nil, err
+	}
+
+	return rows, failed, nil
+}
+
+func failedImportRow(row models.ProductImportRow, err error) models.ImportRowResult {
+	return models.ImportRowResult{
+		Line:       row.Line,
+		ExternalID: row.ExternalID,
+		Action:     models.ImportActionFailed,
+		Error:      err.Error(),
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name         string
+		body         string
+		expectErrIs  error
+		expectRows   []string
+		expectFailed map[int]string
+	}{
+		{
+			name:         "Valid rows",
+			body:         "external_id,name,price,stock\next-1,Mouse,10.00,5\next-2,Keyboard,25.50,\n",
+			expectRows:   []string{"ext-1", "ext-2"},
+			expectFailed: map[int]string{},
+		},
+		{
+			name:       "Stray quote fails only its row",
+			body:       "external_id,name,price\next-1,Mouse,10.00\next-2,Bad \"quote,5.00\next-3,Cable,2.00\n",
+			expectRows: []string{"ext-1", "ext-3"},
+			expectFailed: map[int]string{
+				3: `bare " in non-quoted-field`,
+			},
+		},
+		{
+			name:       "Wrong number of columns",
+			body:       "external_id,name,price\next-1,Mouse\next-2,Keyboard,25.50\n",
+			expectRows: []string{"ext-2"},
+			expectFailed: map[int]string{
+				2: "wrong number of columns",
+			},
+		},
+		{
+			name:       "Invalid price",
+			body:       "external_id,name,price\next-1,Mouse,1.234\n",
+			expectRows: nil,
+			expectFailed: map[int]string{
+				2: "price must be a number with at most 2 decimal places",
+			},
+		},
+		{
+			name:        "Unknown column",
+			body:        "external_id,name,price,colour\n",
+			expectErrIs: ErrInvalidImport,
+		},
+		{
+			name:        "Empty file",
+			body:        "",
+			expectErrIs: ErrInvalidImport,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, failed, err := parseImportCSV(strings.NewReader(tt.body))
+
+			if tt.expectErrIs != nil {
+				assert.True(t, errors.Is(err, tt.expectErrIs), "expected %v, got %v", tt.expectErrIs, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			var externalIDs []string
+			for _, row := range rows {
+				externalIDs = append(externalIDs, row.ExternalID)
+			}
+			assert.Equal(t, tt.expectRows, externalIDs)
+
+			failedLines := make(map[int]string, len(failed))
+			for _, result := range failed {
+				assert.Equal(t, models.ImportActionFailed, result.Action)
+				failedLines[result.Line] = result.Error
+			}
+			assert.Equal(t, tt.expectFailed, failedLines)
+		})
+	}
+}
+
+// importStore records the rows an import writes; every other ProductStore method is left unimplemented
+type importStore struct {
+	store.ProductStore
+	imported []models.ProductImportRow
+}
+
+func (s *importStore) ImportBatchInDB(ctx context.Context, rows []models.ProductImportRow) ([]models.ImportRowResult, error) {
+	s.imported = append(s.imported, rows...)
+	results := make([]models.ImportRowResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, models.ImportRowResult{Line: row.Line, ExternalID: row.ExternalID, Action: models.ImportActionCreated})
+	}
+	return results, nil
+}
+
+func TestImportCurrencyAndTaxClass(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name           string
+		format         string
+		body           string
+		expectCurrency string
+		expectTaxClass string
+		expectErrIs    error
+	}{
+		{
+			name:           "CSV columns are normalized",
+			format:         models.ImportFormatCSV,
+			body:           "external_id,name,price,currency,tax_class\next-1,Mouse,10.00, eur ,Reduced\n",
+			expectCurrency: "EUR",
+			expectTaxClass: "reduced",
+		},
+		{
+			name:           "JSON Lines fields are normalized",
+			format:         models.ImportFormatJSONL,
+			body:           `{"external_id": "ext-1", "name": "Mouse", "price": 10, "currency": "gbp", "tax_class": "zero"}` + "\n",
+			expectCurrency: "GBP",
+			expectTaxClass: "zero",
+		},
+		{
+			name:           "Missing columns take the defaults",
+			format:         models.ImportFormatCSV,
+			body:           "external_id,name,price\next-1,Mouse,10.00\n",
+			expectCurrency: models.DefaultCurrency,
+			expectTaxClass: models.DefaultTaxClass,
+		},
+		{
+			name:           "Empty fields take the defaults",
+			format:         models.ImportFormatCSV,
+			body:           "external_id,name,price,currency,tax_class\next-1,Mouse,10.00,,\n",
+			expectCurrency: models.DefaultCurrency,
+			expectTaxClass: models.DefaultTaxClass,
+		},
+		{
+			name:        "Invalid currency fails the row",
+			format:      models.ImportFormatCSV,
+			body:        "external_id,name,price,currency\next-1,Mouse,10.00,dollars\n",
+			expectErrIs: ErrInvalidCurrency,
+		},
+		{
+			name:        "Invalid tax class fails the row",
+			format:      models.ImportFormatJSONL,
+			body:        `{"external_id": "ext-1", "name": "Mouse", "price": 10, "tax_class": "no tax!"}` + "\n",
+			expectErrIs: ErrInvalidTaxClass,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			productStore := &importStore{}
+			s := &productService{store: productStore}
+
+			report, err := s.Import(context.Background(), tt.format, strings.NewReader(tt.body), false)
+			assert.NoError(t, err)
+
+			if tt.expectErrIs != nil {
+				assert.Empty(t, productStore.imported)
+				if assert.Len(t, report.Rows, 1) {
+					assert.Equal(t, models.ImportActionFailed, report.Rows[0].Action)
+					assert.Contains(t, report.Rows[0].Error, tt.expectErrIs.Error())
+				}
+				return
+			}
+
+			if assert.Len(t, productStore.imported, 1) {
+				assert.Equal(t, tt.expectCurrency, productStore.imported[0].Currency)
+				assert.Equal(t, tt.expectTaxClass, productStore.imported[0].TaxClass)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+)
+
+var (
+	ErrMissingRole     = errors.New("role is required")
+	ErrRoleNotFound    = store.ErrRoleNotFound
+	ErrRoleNotAssigned = store.ErrRoleNotAssigned
+	ErrUserNotFound    = store.ErrUserNotFound
+)
+
+type RoleService interface {
+	GetAll(ctx context.Context) ([]models.Role, error)
+	GetByUser(ctx context.Context, userID string) ([]models.UserRole, error)
+	Assign(ctx context.Context, userID string, roleName string) error
+	Remove(ctx context.Context, userID string, roleName string) error
+}
+
+type roleService struct {
+	store     store.RoleStore
+	userStore store.UserStore
+}
+
+func NewRoleService(store store.RoleStore, userStore store.UserStore) RoleService {
+	return &roleService{
+		store:     store,
+		userStore: userStore,
+	}
+}
+
+func (s *roleService) GetAll(ctx context.Context) ([]models.Role, error) {
+	return s.store.GetAllFromDB(ctx)
+}
+
+func (s *roleService) GetByUser(ctx context.Context, userID string) ([]models.UserRole, error) {
+	if _, err := s.userStore.GetByIdFromDB(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.store.GetByUserFromDB(ctx, userID)
+}
+
+func (s *roleService) Assign(ctx context.Context, userID string, roleName string) error {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return errors.New("user not found in context")
+	}
+
+	roleName = strings.TrimSpace(roleName)
+	if roleName == "" {
+		return ErrMissingRole
+	}
+
+	if _, err := s.userStore.GetByIdFromDB(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.store.AssignInDB(ctx, userID, roleName, user.UserID)
+}
+
+func (s *roleService) Remove(ctx context.Context, userID string, roleName string) error {
+	return s.store.RemoveFromDB(ctx, userID, roleName)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+)
+
+var (
+	ErrInvalidCountry  = models.ErrInvalidCountry
+	ErrInvalidRegion   = models.ErrInvalidRegion
+	ErrInvalidTaxClass = models.ErrInvalidTaxClass
+	ErrInvalidTaxRate  = errors.New("tax rate must be between 0 and 1")
+	ErrTaxRuleNotFound = store.ErrTaxRuleNotFound
+	ErrTaxRuleConflict = store.ErrTaxRuleConflict
+)
+
+type TaxService interface {
+	GetAll(ctx context.Context, includeSuperseded bool) ([]models.TaxRule, error)
+	GetByID(ctx context.Context, ruleID string) (*models.TaxRule, error)
+	Create(ctx context.Context, ruleReq *models.TaxRuleRequest) (*models.TaxRule, error)
+	Retire(ctx context.Context, ruleID string) error
+	// RulesFor returns the rules in force for a normalized country and region
+	RulesFor(ctx context.Context, country string, region string) (models.TaxRules, error)
+}
+
+type taxService struct {
+	store store.TaxStore
+}
+
+func NewTaxService(store store.TaxStore) TaxService {
+	return &taxService{
+		store: store,
+	}
+}
+
+func (s *taxService) GetAll(ctx context.Context, includeSuperseded bool) ([]models.TaxRule, error) {
+	rules, err := s.store.GetAllFromDB(ctx, includeSuperseded)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []models.TaxRule{}
+	}
+
+	return rules, nil
+}
+
+func (s *taxService) GetByID(ctx context.Context, ruleID string) (*models.TaxRule, error) {
+	return s.store.GetByIDFromDB(ctx, ruleID)
+}
+
+func (s *taxService) Create(ctx context.Context, ruleReq *models.TaxRuleRequest) (*models.TaxRule, error) {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return nil, errors.New("user not found in context")
+	}
+
+	country, err := models.NormalizeCountry(ruleReq.Country)
+	if err != nil {
+		return nil, err
+	}
+	region, err := models.NormalizeRegion(ruleReq.Region)
+	if err != nil {
+		return nil, err
+	}
+	taxClass, err := models.NormalizeTaxClass(ruleReq.TaxClass)
+	if err != nil {
+		return nil, err
+	}
+	if ruleReq.Rate.Sign() < 0 || ruleReq.Rate.Rat().Cmp(big.NewRat(1, 1)) > 0 {
+		return nil, ErrInvalidTaxRate
+	}
+
+	// A rule is never edited in place: this becomes its next version
+	rule := models.TaxRule{
+		Country:   country,
+		Region:    region,
+		TaxClass:  taxClass,
+		Rate:      ruleReq.Rate,
+		Inclusive: ruleReq.Inclusive,
+		CreatedBy: &user.UserID,
+	}
+	if err := s.store.CreateVersionInDB(ctx, &rule); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (s *taxService) Retire(ctx context.Context, ruleID string) error {
+	return s.store.RetireInDB(ctx, ruleID)
+}
+
+func (s *taxService) RulesFor(ctx context.Context, country string, region string) (models.TaxRules, error) {
+	rules, err := s.store.GetActiveForRegionFromDB(ctx, country, region)
+	if err != nil {
+		return nil, err
+	}
+
+	// A rule for the region wins over the country-wide rule of the same tax class
+	taxRules := make(models.TaxRules, len(rules))
+	for _, rule := range rules {
+		if existing, ok := taxRules[rule.TaxClass]; ok && existing.Region != "" {
+			continue
+		}
+		taxRules[rule.TaxClass] = rule
+	}
+
+	return taxRules, nil
+}
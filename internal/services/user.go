@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/officiallysidsingh/ecom-server/internal/config"
 	"github.com/officiallysidsingh/ecom-server/internal/models"
@@ -17,88 +19,208 @@ var (
 	ErrEmailExists     = errors.New("email already registered")
 	ErrHashingPassword = errors.New("error hashing password")
 	ErrCreatingUser    = errors.New("error creating user")
+
+	ErrRefreshTokenInvalid = store.ErrRefreshTokenInvalid
+	ErrRefreshTokenExpired = store.ErrRefreshTokenExpired
+	ErrRefreshTokenReused  = store.ErrRefreshTokenReused
 )
 
 type UserService interface {
-	Login(ctx context.Context, loginReq *models.LoginRequest) (*models.User, string, error)
-	Signup(ctx context.Context, user *models.SignupRequest) (string, string, error)
+	Login(ctx context.Context, loginReq *models.LoginRequest) (*models.User, *models.AuthTokens, error)
+	Signup(ctx context.Context, user *models.SignupRequest) (string, *models.AuthTokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.AuthTokens, error)
+	Logout(ctx context.Context, refreshToken string) error
+	LogoutAll(ctx context.Context) error
 }
 
 type userService struct {
-	store     store.UserStore
-	jwtSecret string
+	store      store.UserStore
+	tokenStore store.TokenStore
+	keys       *utils.KeySet
 }
 
-func NewUserService(store store.UserStore, envConfig *config.EnvConfig) UserService {
+func NewUserService(store store.UserStore, tokenStore store.TokenStore, keys *utils.KeySet) UserService {
 	return &userService{
-		store:     store,
-		jwtSecret: envConfig.JWT_SECRET,
+		store:      store,
+		tokenStore: tokenStore,
+		keys:       keys,
 	}
 }
 
-func (s *userService) Login(ctx context.Context, loginReq *models.LoginRequest) (*models.User, string, error) {
+func (s *userService) Login(ctx context.Context, loginReq *models.LoginRequest) (*models.User, *models.AuthTokens, error) {
 	// Fetch user from DB by Email
 	user, err := s.store.GetByEmailFromDB(ctx, loginReq.Email)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Verify the password using bcrypt
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginReq.Password))
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Create JWT Config
-	tokenConfig := config.NewJWTConfig(s.jwtSecret)
-
-	// Generate JWT token
-	token, err := utils.GenerateJWT(user.UserID, user.Role, tokenConfig)
+	// Generate access and refresh tokens
+	tokens, err := s.issueTokens(ctx, user.UserID, user.Role, user.TokenVersion)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Return user data
 	user.Password = ""
-	return user, token, nil
+	return user, tokens, nil
 }
 
-func (s *userService) Signup(ctx context.Context, user *models.SignupRequest) (string, string, error) {
+func (s *userService) Signup(ctx context.Context, user *models.SignupRequest) (string, *models.AuthTokens, error) {
 	// Check if email already exists
 	existingUser, err := s.store.GetByEmailFromDB(ctx, user.Email)
 	if err != nil && !strings.Contains(err.Error(), "not found") {
-		return "", "", fmt.Errorf("error checking email: %w", err)
+		return "", nil, fmt.Errorf("error checking email: %w", err)
 	}
 
 	if existingUser != nil {
-		return "", "", ErrEmailExists
+		return "", nil, ErrEmailExists
 	}
 
 	// If email doesn't exist
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", "", fmt.Errorf("%w: %v", ErrHashingPassword, err)
+		return "", nil, fmt.Errorf("%w: %v", ErrHashingPassword, err)
 	}
 
 	// Prepare user for creation
 	user.Password = string(hashedPassword)
-	user.Role = "user"
+	user.Role = models.RoleUser
 
 	// Create user in DB
 	userID, err := s.store.CreateInDB(ctx, user)
 	if err != nil {
-		return "", "", fmt.Errorf("%w: %v", ErrCreatingUser, err)
+		return "", nil, fmt.Errorf("%w: %v", ErrCreatingUser, err)
+	}
+
+	// Generate access and refresh tokens
+	tokens, err := s.issueTokens(ctx, userID, user.Role, 0)
+	if err != nil {
+		return "", nil, fmt.Errorf("user created but error generating token: %w", err)
+	}
+
+	return userID, tokens, nil
+}
+
+func (s *userService) Refresh(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
+	if refreshToken == "" {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// Create JWT Config
+	tokenConfig := config.NewJWTConfig()
+
+	// Generate the successor refresh token
+	newRefreshToken, newTokenHash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := time.Now().Add(tokenConfig.RefreshDuration)
+
+	// Rotate the presented token, which also catches reuse
+	rotated, err := s.tokenStore.RotateRefreshTokenInDB(
+		ctx,
+		utils.HashRefreshToken(refreshToken),
+		newTokenHash,
+		refreshExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-read the user so the new access token carries the current role
+	user, err := s.store.GetByIdFromDB(ctx, rotated.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate JWT token
+	accessToken, err := utils.GenerateJWT(user.UserID, user.Role, user.TokenVersion, s.keys, tokenConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  time.Now().Add(tokenConfig.TokenExpiration),
+		RefreshToken:     newRefreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func (s *userService) Logout(ctx context.Context, refreshToken string) error {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return errors.New("user not found in context")
+	}
+
+	// Deny the access token until it would have expired anyway
+	if err := s.tokenStore.RevokeAccessTokenInDB(ctx, user.ID, user.UserID, user.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	// End the refresh token family of this session
+	if refreshToken != "" {
+		err := s.tokenStore.RevokeRefreshTokenFamilyInDB(ctx, utils.HashRefreshToken(refreshToken))
+		if err != nil && !errors.Is(err, ErrRefreshTokenInvalid) {
+			return err
+		}
 	}
 
+	return nil
+}
+
+func (s *userService) LogoutAll(ctx context.Context) error {
+	// Retrieve user from context
+	user, ok := ctx.Value(userContextKey).(models.Claims)
+	if !ok {
+		log.Println("User not found in context")
+		return errors.New("user not found in context")
+	}
+
+	// Bumping the version rejects every access token issued so far, and the refresh tokens
+	// go in the same transaction so none of them can mint a token at the new version
+	return s.tokenStore.RevokeAllTokensInDB(ctx, user.UserID)
+}
+
+// Generate an access JWT and store a refresh token starting a new token family
+func (s *userService) issueTokens(ctx context.Context, userID string, role string, tokenVersion int) (*models.AuthTokens, error) {
 	// Create JWT Config
-	tokenConfig := config.NewJWTConfig(s.jwtSecret)
+	tokenConfig := config.NewJWTConfig()
 
 	// Generate JWT token
-	token, err := utils.GenerateJWT(userID, user.Role, tokenConfig)
+	accessToken, err := utils.GenerateJWT(userID, role, tokenVersion, s.keys, tokenConfig)
 	if err != nil {
-		return "", "", fmt.Errorf("user created but error generating token: %w", err)
+		return nil, err
+	}
+
+	// Generate refresh token
+	refreshToken, tokenHash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiresAt := time.Now().Add(tokenConfig.RefreshDuration)
+	if _, err := s.tokenStore.CreateRefreshTokenInDB(ctx, &models.RefreshToken{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: refreshExpiresAt,
+	}); err != nil {
+		return nil, err
 	}
 
-	return userID, token, nil
+	return &models.AuthTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  time.Now().Add(tokenConfig.TokenExpiration),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
 }
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type localBackend struct {
+	dir       string
+	baseURL   string
+	mountPath string
+}
+
+// NewLocalBackend stores files under dir and serves them from baseURL, e.g. http://localhost:8080/uploads
+func NewLocalBackend(dir string, baseURL string) (Backend, error) {
+	// Files are served under the path of the base URL, so URL and route can never disagree
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage URL %q: %w", baseURL, err)
+	}
+	mountPath := path.Clean("/" + parsed.Path)
+	if mountPath == "/" {
+		return nil, fmt.Errorf("invalid storage URL %q: needs a path such as /uploads", baseURL)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory %s: %w", dir, err)
+	}
+
+	return &localBackend{
+		dir:       dir,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		mountPath: mountPath,
+	}, nil
+}
+
+func (b *localBackend) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	filePath, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+
+	// Write to a temp file first so readers never see a half-written object
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file for %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	log.Printf("Stored %s (%s) in local storage", key, contentType)
+	return nil
+}
+
+func (b *localBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	filePath, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", key, err)
+	}
+
+	return file, nil
+}
+
+func (b *localBackend) Delete(ctx context.Context, key string) error {
+	filePath, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+		}
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (b *localBackend) URL(key string) string {
+	return b.baseURL + "/" + key
+}
+
+func (b *localBackend) Handler() (string, http.Handler) {
+	return b.mountPath, http.StripPrefix(b.mountPath+"/", http.HandlerFunc(b.serveFile))
+}
+
+// Serve a single stored file. Keys go through the same checks as writes and directories are never listed.
+func (b *localBackend) serveFile(w http.ResponseWriter, r *http.Request) {
+	filePath, err := b.path(r.URL.Path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Error opening %s from local storage: %v", r.URL.Path, err)
+		}
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// Resolve a key to a file inside the storage directory, refusing keys that would escape it
+func (b *localBackend) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || cleaned != "/"+key {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return filepath.Join(b.dir, filepath.FromSlash(cleaned)), nil
+}
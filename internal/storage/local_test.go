@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBackend(t *testing.T) *localBackend {
+	t.Helper()
+
+	backend, err := NewLocalBackend(t.TempDir(), "http://localhost:8080/uploads/")
+	require.NoError(t, err)
+	return backend.(*localBackend)
+}
+
+func TestLocalBackendPath(t *testing.T) {
+	b := newTestBackend(t)
+
+	// Write testcases
+	tests := []struct {
+		name       string
+		key        string
+		expectErr  bool
+		expectPath string
+	}{
+		{
+			name:       "Nested key",
+			key:        "products/p1/abc.png",
+			expectPath: filepath.Join(b.dir, "products", "p1", "abc.png"),
+		},
+		{
+			name:       "Single file",
+			key:        "abc.png",
+			expectPath: filepath.Join(b.dir, "abc.png"),
+		},
+		{name: "Empty key", key: "", expectErr: true},
+		{name: "Parent directory", key: "..", expectErr: true},
+		{name: "Escapes the directory", key: "../secret.pem", expectErr: true},
+		{name: "Escapes after a prefix", key: "products/../../secret.pem", expectErr: true},
+		{name: "Parent inside the directory", key: "products/../abc.png", expectErr: true},
+		{name: "Leading slash", key: "/etc/passwd", expectErr: true},
+		{name: "Root", key: "/", expectErr: true},
+		{name: "Trailing slash", key: "products/", expectErr: true},
+		{name: "Double slash", key: "products//abc.png", expectErr: true},
+		{name: "Current directory", key: "./abc.png", expectErr: true},
+		{name: "Dot", key: ".", expectErr: true},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath, err := b.path(tt.key)
+
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidKey)
+				assert.Empty(t, filePath)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectPath, filePath)
+			}
+		})
+	}
+}
+
+func TestNewLocalBackend(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name            string
+		baseURL         string
+		expectErr       bool
+		expectMountPath string
+		expectURL       string
+	}{
+		{
+			name:            "Absolute URL",
+			baseURL:         "http://localhost:8080/uploads",
+			expectMountPath: "/uploads",
+			expectURL:       "http://localhost:8080/uploads/products/p1/abc.png",
+		},
+		{
+			name:            "Trailing slash",
+			baseURL:         "https://cdn.example.com/static/files/",
+			expectMountPath: "/static/files",
+			expectURL:       "https://cdn.example.com/static/files/products/p1/abc.png",
+		},
+		{
+			name:            "Path only",
+			baseURL:         "/media",
+			expectMountPath: "/media",
+			expectURL:       "/media/products/p1/abc.png",
+		},
+		{name: "No path", baseURL: "http://localhost:8080", expectErr: true},
+		{name: "Root path", baseURL: "http://localhost:8080/", expectErr: true},
+		{name: "Invalid URL", baseURL: "http://[::1", expectErr: true},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend, err := NewLocalBackend(t.TempDir(), tt.baseURL)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, backend)
+				return
+			}
+			require.NoError(t, err)
+
+			mountPath, _ := backend.(Server).Handler()
+			assert.Equal(t, tt.expectMountPath, mountPath)
+			assert.Equal(t, tt.expectURL, backend.URL("products/p1/abc.png"))
+		})
+	}
+}
+
+func TestLocalBackendHandler(t *testing.T) {
+	b := newTestBackend(t)
+	require.NoError(t, b.Put(context.Background(), "products/p1/abc.txt", strings.NewReader("hello"), "text/plain"))
+
+	// A file next to the storage directory that must never be reachable
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(b.dir), "secret.txt"), []byte("secret"), 0o600))
+
+	mountPath, handler := b.Handler()
+	require.Equal(t, "/uploads", mountPath)
+
+	// Write testcases
+	tests := []struct {
+		name         string
+		target       string
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			name:         "Stored file",
+			target:       "/uploads/products/p1/abc.txt",
+			expectStatus: http.StatusOK,
+			expectBody:   "hello",
+		},
+		{
+			name:         "Missing file",
+			target:       "/uploads/products/p1/missing.txt",
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:         "Directories are not listed",
+			target:       "/uploads/products/p1",
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:         "Directory with trailing slash",
+			target:       "/uploads/products/",
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:         "Storage root",
+			target:       "/uploads/",
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:         "Encoded traversal",
+			target:       "/uploads/%2e%2e/secret.txt",
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:         "Outside the mount path",
+			target:       "/other/products/p1/abc.txt",
+			expectStatus: http.StatusNotFound,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectStatus, rec.Code)
+			if tt.expectBody != "" {
+				body, err := io.ReadAll(rec.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectBody, string(body))
+				assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrInvalidKey     = errors.New("invalid object key")
+)
+
+// Backend stores uploaded files under a slash-separated key
+type Backend interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns the address clients can fetch the object from
+	URL(key string) string
+}
+
+// Server is implemented by backends whose files are served by this app rather than by the store itself
+type Server interface {
+	// Handler returns the URL path files are served under, derived from the base URL, and the handler serving them
+	Handler() (string, http.Handler)
+}
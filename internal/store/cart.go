@@ -0,0 +1,334 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+var (
+	ErrCartItemNotFound = errors.New("cart item not found")
+	ErrCartChanged      = errors.New("cart changed during checkout")
+)
+
+type CartStore interface {
+	GetByUserFromDB(ctx context.Context, userID string) ([]models.CartItem, error)
+	AddItemInDB(ctx context.Context, userID string, productID string, variantID string, quantity int) error
+	UpdateItemInDB(ctx context.Context, userID string, variantID string, quantity int) error
+	RemoveItemFromDB(ctx context.Context, userID string, variantID string) error
+	ClearFromDB(ctx context.Context, userID string) error
+}
+
+type cartStore struct {
+	db *sqlx.DB
+}
+
+func NewCartStore(db *sqlx.DB) CartStore {
+	return &cartStore{
+		db: db,
+	}
+}
+
+func (s *cartStore) GetByUserFromDB(ctx context.Context, userID string) ([]models.CartItem, error) {
+	var items []models.CartItem
+
+	// SQL query to get the cart of a user with current variant data
+	query := `
+		SELECT ci.product_id, ci.variant_id, v.sku, v.options, p.name,
+			COALESCE(v.price, p.price) AS unit_price, p.currency, v.stock,
+			ci.quantity, ci.created_at, ci.updated_at
+		FROM cart_items ci
+		JOIN product_variants v ON v.variant_id = ci.variant_id
+		JOIN products p ON p.product_id = ci.product_id
+		WHERE ci.user_id = $1
+		ORDER BY ci.created_at
+	`
+
+	fields := []interface{}{
+		userID,
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&items,
+	); err != nil {
+		log.Printf("Error fetching cart of user with ID %s from DB: %v", userID, err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (s *cartStore) AddItemInDB(ctx context.Context, userID string, productID string, variantID string, quantity int) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to add a product variant to the cart, or add to its quantity if already there,
+	// falling back to the default variant when none was picked
+	query := `
+		INSERT INTO cart_items (user_id, product_id, variant_id, quantity, created_at, updated_at)
+		SELECT $1, v.product_id, v.variant_id, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		FROM product_variants v
+		JOIN products p ON p.product_id = v.product_id
+		WHERE v.product_id = $2
+		AND p.deleted_at IS NULL
+		AND (v.variant_id::text = $3 OR ($3 = '' AND v.is_default))
+		ON CONFLICT (user_id, variant_id)
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = CURRENT_TIMESTAMP
+		RETURNING variant_id
+	`
+
+	fields := []interface{}{
+		userID,
+		productID,
+		variantID,
+		quantity,
+	}
+
+	// Execute the query and return the added variant ID
+	var addedVariantID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&addedVariantID,
+	)
+	if txErr != nil {
+		// If no rows affected (Product or Variant Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			if variantID != "" {
+				log.Printf("Variant with ID %s of product with ID %s not found", variantID, productID)
+				return fmt.Errorf("%w: variant with ID %s of product with ID %s", ErrVariantNotFound, variantID, productID)
+			}
+			log.Printf("Product with ID %s not found", productID)
+			return fmt.Errorf("%w: product with ID %s", ErrProductNotFound, productID)
+		}
+		// General error
+		log.Printf("Error adding product to cart in DB: %v", txErr)
+		return fmt.Errorf("failed to add product with ID %s to cart: %w", productID, txErr)
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for cart of user with ID %s: %v", userID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Variant with ID %s added to cart of user with ID %s", addedVariantID, userID)
+	return nil
+}
+
+func (s *cartStore) UpdateItemInDB(ctx context.Context, userID string, variantID string, quantity int) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to set the quantity of a cart item
+	query := `
+		UPDATE cart_items
+		SET quantity = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2
+		AND variant_id = $3
+		RETURNING variant_id
+	`
+
+	fields := []interface{}{
+		quantity,
+		userID,
+		variantID,
+	}
+
+	// Execute the query and return the updated variant ID
+	var updatedVariantID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&updatedVariantID,
+	)
+	if txErr != nil {
+		// If no rows affected (Item Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Variant with ID %s not in cart of user with ID %s", variantID, userID)
+			return fmt.Errorf("%w: variant with ID %s", ErrCartItemNotFound, variantID)
+		}
+		// General error
+		log.Printf("Error updating cart item in DB: %v", txErr)
+		return fmt.Errorf("failed to update cart item with variant ID %s: %w", variantID, txErr)
+	}
+
+	// Commit the transaction if update was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for cart of user with ID %s: %v", userID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Cart item with variant ID %s updated successfully", updatedVariantID)
+	return nil
+}
+
+func (s *cartStore) RemoveItemFromDB(ctx context.Context, userID string, variantID string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to remove an item from the cart
+	query := `
+		DELETE FROM cart_items
+		WHERE user_id = $1
+		AND variant_id = $2
+		RETURNING variant_id
+	`
+
+	fields := []interface{}{
+		userID,
+		variantID,
+	}
+
+	// Execute the query and return the removed variant ID
+	var removedVariantID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&removedVariantID,
+	)
+	if txErr != nil {
+		// If no rows affected (Item Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Variant with ID %s not in cart of user with ID %s", variantID, userID)
+			return fmt.Errorf("%w: variant with ID %s", ErrCartItemNotFound, variantID)
+		}
+		// General error
+		log.Printf("Error removing cart item in DB: %v", txErr)
+		return fmt.Errorf("failed to remove cart item with variant ID %s: %w", variantID, txErr)
+	}
+
+	// Commit the transaction if delete was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for cart of user with ID %s: %v", userID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Cart item with variant ID %s removed successfully", removedVariantID)
+	return nil
+}
+
+func (s *cartStore) ClearFromDB(ctx context.Context, userID string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to empty the cart of a user
+	query := `
+		DELETE FROM cart_items
+		WHERE user_id = $1
+	`
+
+	fields := []interface{}{
+		userID,
+	}
+
+	if _, txErr = utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+	); txErr != nil {
+		log.Printf("Error clearing cart of user with ID %s in DB: %v", userID, txErr)
+		return fmt.Errorf("failed to clear cart of user with ID %s: %w", userID, txErr)
+	}
+
+	// Commit the transaction if delete was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for cart of user with ID %s: %v", userID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Cart of user with ID %s cleared successfully", userID)
+	return nil
+}
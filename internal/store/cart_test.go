@@ -0,0 +1,135 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCartAddItemInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewCartStore(db)
+	defer db.Close()
+
+	addQuery := regexp.QuoteMeta(`
+		INSERT INTO cart_items (user_id, product_id, variant_id, quantity, created_at, updated_at)
+		SELECT $1, v.product_id, v.variant_id, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		FROM product_variants v
+		JOIN products p ON p.product_id = v.product_id
+		WHERE v.product_id = $2
+		AND p.deleted_at IS NULL
+		AND (v.variant_id::text = $3 OR ($3 = '' AND v.is_default))
+		ON CONFLICT (user_id, variant_id)
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = CURRENT_TIMESTAMP
+		RETURNING variant_id
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		variantID   string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful add of default variant",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(addQuery).
+					WithArgs("user-1", "prod-1", "", 2).
+					WillReturnRows(sqlmock.NewRows([]string{"variant_id"}).AddRow("var-1"))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name:      "Successful add of picked variant",
+			variantID: "var-2",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(addQuery).
+					WithArgs("user-1", "prod-1", "var-2", 2).
+					WillReturnRows(sqlmock.NewRows([]string{"variant_id"}).AddRow("var-2"))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name:      "Variant not found",
+			variantID: "var-9",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(addQuery).
+					WithArgs("user-1", "prod-1", "var-9", 2).
+					WillReturnRows(sqlmock.NewRows([]string{"variant_id"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrVariantNotFound,
+		},
+		{
+			name: "Product not found",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(addQuery).
+					WithArgs("user-1", "prod-1", "", 2).
+					WillReturnRows(sqlmock.NewRows([]string{"variant_id"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrProductNotFound,
+		},
+		{
+			name: "Query execution error",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(addQuery).
+					WithArgs("user-1", "prod-1", "", 2).
+					WillReturnError(errors.New("query error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.AddItemInDB(context.Background(), "user-1", "prod-1", tt.variantID, 2)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
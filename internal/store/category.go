@@ -0,0 +1,504 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+var (
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("category cannot be moved under itself or its descendants")
+	ErrCategoryHasChildren    = errors.New("category still has subcategories")
+)
+
+type CategoryStore interface {
+	GetTreeFromDB(ctx context.Context) ([]*models.CategoryNode, error)
+	GetByIDFromDB(ctx context.Context, categoryID string) (*models.Category, error)
+	CreateInDB(ctx context.Context, category *models.Category) (string, error)
+	UpdateInDB(ctx context.Context, category *models.Category, categoryID string) error
+	DeleteFromDB(ctx context.Context, categoryID string) error
+	AddProductInDB(ctx context.Context, categoryID string, productID string) error
+	RemoveProductFromDB(ctx context.Context, categoryID string, productID string) error
+}
+
+type categoryStore struct {
+	db *sqlx.DB
+}
+
+func NewCategoryStore(db *sqlx.DB) CategoryStore {
+	return &categoryStore{
+		db: db,
+	}
+}
+
+func (s *categoryStore) GetTreeFromDB(ctx context.Context) ([]*models.CategoryNode, error) {
+	var nodes []*models.CategoryNode
+
+	// SQL query to walk the whole hierarchy from the roots down, parents before children
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT category_id, parent_id, name, COALESCE(description, '') AS description,
+				created_at, updated_at, 0 AS depth, ARRAY[name::text] AS path
+			FROM categories
+			WHERE parent_id IS NULL
+			UNION ALL
+			SELECT c.category_id, c.parent_id, c.name, COALESCE(c.description, ''),
+				c.created_at, c.updated_at, t.depth + 1, t.path || c.name::text
+			FROM categories c
+			JOIN tree t ON c.parent_id = t.category_id
+		)
+		SELECT category_id, parent_id, name, description, created_at, updated_at, depth
+		FROM tree
+		ORDER BY path
+	`
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		nil,
+		&nodes,
+	); err != nil {
+		log.Printf("Error fetching category tree from DB: %v", err)
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+func (s *categoryStore) GetByIDFromDB(ctx context.Context, categoryID string) (*models.Category, error) {
+	var category models.Category
+
+	// SQL query to get a category by id
+	query := `
+		SELECT category_id, parent_id, name, COALESCE(description, '') AS description, created_at, updated_at
+		FROM categories
+		WHERE category_id = $1
+	`
+
+	fields := []interface{}{
+		categoryID,
+	}
+
+	if err := utils.ExecGetQuery(
+		s.db,
+		query,
+		fields,
+		&category,
+	); err != nil {
+		// If no rows found
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Category with ID %s not found", categoryID)
+			return nil, fmt.Errorf("%w: category with ID %s", ErrCategoryNotFound, categoryID)
+		}
+		log.Printf("Error fetching category with ID %s from DB: %v", categoryID, err)
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (s *categoryStore) CreateInDB(ctx context.Context, category *models.Category) (string, error) {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return "", fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to insert a new category, only if its parent exists
+	query := `
+		INSERT INTO categories (category_id, parent_id, name, description, created_at, updated_at)
+		SELECT gen_random_uuid(), $1::uuid, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		WHERE $1::uuid IS NULL
+		OR EXISTS (SELECT 1 FROM categories WHERE category_id = $1::uuid)
+		RETURNING category_id
+	`
+
+	fields := []interface{}{
+		category.ParentID,
+		category.Name,
+		category.Description,
+	}
+
+	// Execute the query and return the added category ID
+	var categoryID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&categoryID,
+	)
+	if txErr != nil {
+		// If no rows affected (Parent Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Parent category with ID %s not found", *category.ParentID)
+			return "", fmt.Errorf("%w: category with ID %s", ErrParentCategoryNotFound, *category.ParentID)
+		}
+		// General error
+		log.Printf("Error adding category with Name %s to DB: %v", category.Name, txErr)
+		return "", txErr
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for category with ID %s: %v", categoryID, txErr)
+		return "", fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success and return the added category ID
+	log.Printf("Category with ID %s added successfully", categoryID)
+	return categoryID, nil
+}
+
+func (s *categoryStore) UpdateInDB(ctx context.Context, category *models.Category, categoryID string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	if category.ParentID != nil {
+		// SQL query to check the new parent exists and is not inside the subtree being moved
+		query := `
+			WITH RECURSIVE subtree AS (
+				SELECT category_id
+				FROM categories
+				WHERE category_id = $1
+				UNION ALL
+				SELECT c.category_id
+				FROM categories c
+				JOIN subtree st ON c.parent_id = st.category_id
+			)
+			SELECT
+				EXISTS (SELECT 1 FROM categories WHERE category_id = $2) AS parent_exists,
+				EXISTS (SELECT 1 FROM subtree WHERE category_id = $2) AS creates_cycle
+		`
+
+		fields := []interface{}{
+			categoryID,
+			*category.ParentID,
+		}
+
+		var check struct {
+			ParentExists bool `db:"parent_exists"`
+			CreatesCycle bool `db:"creates_cycle"`
+		}
+		txErr = utils.ExecGetTransactionQuery(
+			s.db,
+			tx,
+			query,
+			fields,
+			&check,
+		)
+		if txErr != nil {
+			log.Printf("Error checking parent of category with ID %s in DB: %v", categoryID, txErr)
+			return fmt.Errorf("failed to update category with ID %s: %w", categoryID, txErr)
+		}
+		if !check.ParentExists {
+			txErr = fmt.Errorf("%w: category with ID %s", ErrParentCategoryNotFound, *category.ParentID)
+			return txErr
+		}
+		if check.CreatesCycle {
+			txErr = fmt.Errorf("%w: category with ID %s", ErrCategoryCycle, *category.ParentID)
+			return txErr
+		}
+	}
+
+	// SQL query to update a category
+	query := `
+		UPDATE categories
+		SET parent_id = $1, name = $2, description = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE category_id = $4
+		RETURNING category_id
+	`
+
+	fields := []interface{}{
+		category.ParentID,
+		category.Name,
+		category.Description,
+		categoryID,
+	}
+
+	// Execute the query and return the updated category ID
+	var updatedCategoryID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&updatedCategoryID,
+	)
+	if txErr != nil {
+		// If no rows affected (Category Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Category with ID %s not found", categoryID)
+			return fmt.Errorf("%w: category with ID %s", ErrCategoryNotFound, categoryID)
+		}
+		// General error
+		log.Printf("Error updating category in DB: %v", txErr)
+		return fmt.Errorf("failed to update category with ID %s: %w", categoryID, txErr)
+	}
+
+	// Commit the transaction if update was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for category with ID %s: %v", categoryID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Category with ID %s updated successfully", updatedCategoryID)
+	return nil
+}
+
+func (s *categoryStore) DeleteFromDB(ctx context.Context, categoryID string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to delete a category, reporting whether it still had subcategories
+	query := `
+		WITH children AS (
+			SELECT COUNT(*) AS count
+			FROM categories
+			WHERE parent_id = $1
+		), deleted AS (
+			DELETE FROM categories
+			WHERE category_id = $1
+			AND (SELECT count FROM children) = 0
+			RETURNING category_id
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM categories WHERE category_id = $1) AS category_exists,
+			(SELECT count FROM children) > 0 AS has_children
+	`
+
+	fields := []interface{}{
+		categoryID,
+	}
+
+	var result struct {
+		CategoryExists bool `db:"category_exists"`
+		HasChildren    bool `db:"has_children"`
+	}
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&result,
+	)
+	if txErr != nil {
+		log.Printf("Error deleting category in DB: %v", txErr)
+		return fmt.Errorf("failed to delete category with ID %s: %w", categoryID, txErr)
+	}
+	if !result.CategoryExists {
+		txErr = fmt.Errorf("%w: category with ID %s", ErrCategoryNotFound, categoryID)
+		return txErr
+	}
+	if result.HasChildren {
+		txErr = fmt.Errorf("%w: category with ID %s", ErrCategoryHasChildren, categoryID)
+		return txErr
+	}
+
+	// Commit the transaction if delete was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for category with ID %s: %v", categoryID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Category with ID %s deleted successfully", categoryID)
+	return nil
+}
+
+func (s *categoryStore) AddProductInDB(ctx context.Context, categoryID string, productID string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to link a product to a category, returning the link even if it already existed
+	query := `
+		INSERT INTO product_categories (product_id, category_id)
+		SELECT p.product_id, c.category_id
+		FROM products p, categories c
+		WHERE p.product_id = $1
+		AND p.deleted_at IS NULL
+		AND c.category_id = $2
+		ON CONFLICT (product_id, category_id)
+		DO UPDATE SET product_id = EXCLUDED.product_id
+		RETURNING product_id
+	`
+
+	fields := []interface{}{
+		productID,
+		categoryID,
+	}
+
+	// Execute the query and return the linked product ID
+	var linkedProductID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&linkedProductID,
+	)
+	if txErr != nil {
+		// If no rows affected (Product archived, or Product or Category Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Product with ID %s or category with ID %s not found", productID, categoryID)
+			return fmt.Errorf("%w: product with ID %s", ErrProductNotFound, productID)
+		}
+		// General error
+		log.Printf("Error adding product to category in DB: %v", txErr)
+		return fmt.Errorf("failed to add product with ID %s to category with ID %s: %w", productID, categoryID, txErr)
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for category with ID %s: %v", categoryID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Product with ID %s added to category with ID %s", linkedProductID, categoryID)
+	return nil
+}
+
+func (s *categoryStore) RemoveProductFromDB(ctx context.Context, categoryID string, productID string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to unlink a product from a category
+	query := `
+		DELETE FROM product_categories
+		WHERE product_id = $1
+		AND category_id = $2
+		RETURNING product_id
+	`
+
+	fields := []interface{}{
+		productID,
+		categoryID,
+	}
+
+	// Execute the query and return the unlinked product ID
+	var unlinkedProductID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&unlinkedProductID,
+	)
+	if txErr != nil {
+		// If no rows affected (Link Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Product with ID %s not in category with ID %s", productID, categoryID)
+			return fmt.Errorf("%w: product with ID %s in category with ID %s", ErrProductNotFound, productID, categoryID)
+		}
+		// General error
+		log.Printf("Error removing product from category in DB: %v", txErr)
+		return fmt.Errorf("failed to remove product with ID %s from category with ID %s: %w", productID, categoryID, txErr)
+	}
+
+	// Commit the transaction if delete was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for category with ID %s: %v", categoryID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Product with ID %s removed from category with ID %s", unlinkedProductID, categoryID)
+	return nil
+}
@@ -0,0 +1,235 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryUpdateInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewCategoryStore(db)
+	defer db.Close()
+
+	parentID := "cat-parent"
+	category := &models.Category{
+		ParentID: &parentID,
+		Name:     "Running",
+	}
+
+	checkQuery := regexp.QuoteMeta(`
+		EXISTS (SELECT 1 FROM categories WHERE category_id = $2) AS parent_exists,
+		EXISTS (SELECT 1 FROM subtree WHERE category_id = $2) AS creates_cycle
+	`)
+	updateQuery := regexp.QuoteMeta(`
+		UPDATE categories
+		SET parent_id = $1, name = $2, description = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE category_id = $4
+		RETURNING category_id
+	`)
+	checkColumns := []string{"parent_exists", "creates_cycle"}
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful move",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(checkQuery).
+					WithArgs("cat-1", parentID).
+					WillReturnRows(sqlmock.NewRows(checkColumns).AddRow(true, false))
+
+				mock.ExpectQuery(updateQuery).
+					WithArgs(&parentID, "Running", "", "cat-1").
+					WillReturnRows(sqlmock.NewRows([]string{"category_id"}).AddRow("cat-1"))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name: "Parent not found",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(checkQuery).
+					WithArgs("cat-1", parentID).
+					WillReturnRows(sqlmock.NewRows(checkColumns).AddRow(false, false))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrParentCategoryNotFound,
+		},
+		{
+			name: "Move under own descendant",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(checkQuery).
+					WithArgs("cat-1", parentID).
+					WillReturnRows(sqlmock.NewRows(checkColumns).AddRow(true, true))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrCategoryCycle,
+		},
+		{
+			name: "Category not found",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(checkQuery).
+					WithArgs("cat-1", parentID).
+					WillReturnRows(sqlmock.NewRows(checkColumns).AddRow(true, false))
+
+				mock.ExpectQuery(updateQuery).
+					WithArgs(&parentID, "Running", "", "cat-1").
+					WillReturnRows(sqlmock.NewRows([]string{"category_id"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrCategoryNotFound,
+		},
+		{
+			name: "Query execution error",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(checkQuery).
+					WithArgs("cat-1", parentID).
+					WillReturnError(errors.New("query error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.UpdateInDB(context.Background(), category, "cat-1")
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCategoryAddProductInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewCategoryStore(db)
+	defer db.Close()
+
+	linkQuery := regexp.QuoteMeta(`
+		INSERT INTO product_categories (product_id, category_id)
+		SELECT p.product_id, c.category_id
+		FROM products p, categories c
+		WHERE p.product_id = $1
+		AND p.deleted_at IS NULL
+		AND c.category_id = $2
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful link",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(linkQuery).
+					WithArgs("prod-1", "cat-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow("prod-1"))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name: "Archived or missing product (No rows returned)",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(linkQuery).
+					WithArgs("prod-1", "cat-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrProductNotFound,
+		},
+		{
+			name: "Query execution error",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(linkQuery).
+					WithArgs("prod-1", "cat-1").
+					WillReturnError(errors.New("query error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.AddProductInDB(context.Background(), "cat-1", "prod-1")
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+var (
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+)
+
+type ExchangeRateStore interface {
+	GetAllFromDB(ctx context.Context) ([]models.ExchangeRate, error)
+	GetRateFromDB(ctx context.Context, baseCurrency string, quoteCurrency string) (models.Rate, error)
+	UpsertInDB(ctx context.Context, rate *models.ExchangeRate) error
+	DeleteFromDB(ctx context.Context, baseCurrency string, quoteCurrency string) error
+}
+
+type exchangeRateStore struct {
+	db *sqlx.DB
+}
+
+func NewExchangeRateStore(db *sqlx.DB) ExchangeRateStore {
+	return &exchangeRateStore{
+		db: db,
+	}
+}
+
+func (s *exchangeRateStore) GetAllFromDB(ctx context.Context) ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+
+	// SQL query to get all exchange rates
+	query := `
+		SELECT base_currency, quote_currency, rate, updated_at
+		FROM exchange_rates
+		ORDER BY base_currency, quote_currency
+	`
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		nil,
+		&rates,
+	); err != nil {
+		log.Printf("Error fetching exchange rates from DB: %v", err)
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (s *exchangeRateStore) GetRateFromDB(ctx context.Context, baseCurrency string, quoteCurrency string) (models.Rate, error) {
+	// A currency always converts to itself unchanged
+	if baseCurrency == quoteCurrency {
+		return models.UnitRate(), nil
+	}
+
+	var rate models.Rate
+
+	// SQL query to get the rate of a currency pair
+	query := `
+		SELECT rate
+		FROM exchange_rates
+		WHERE base_currency = $1
+		AND quote_currency = $2
+	`
+
+	fields := []interface{}{
+		baseCurrency,
+		quoteCurrency,
+	}
+
+	if err := utils.ExecGetQuery(
+		s.db,
+		query,
+		fields,
+		&rate,
+	); err != nil {
+		// If no rows found
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Exchange rate from %s to %s not found", baseCurrency, quoteCurrency)
+			return rate, fmt.Errorf("%w: from %s to %s", ErrExchangeRateNotFound, baseCurrency, quoteCurrency)
+		}
+		log.Printf("Error fetching exchange rate from %s to %s from DB: %v", baseCurrency, quoteCurrency, err)
+		return rate, err
+	}
+
+	return rate, nil
+}
+
+func (s *exchangeRateStore) UpsertInDB(ctx context.Context, rate *models.ExchangeRate) error {
+	// SQL query to set the rate of a currency pair, replacing any earlier one
+	query := `
+		INSERT INTO exchange_rates (base_currency, quote_currency, rate, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (base_currency, quote_currency)
+		DO UPDATE SET rate = EXCLUDED.rate, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	fields := []interface{}{
+		rate.BaseCurrency,
+		rate.QuoteCurrency,
+		rate.Rate,
+	}
+
+	if err := utils.ExecGetQuery(
+		s.db,
+		query,
+		fields,
+		&rate.UpdatedAt,
+	); err != nil {
+		log.Printf("Error setting exchange rate from %s to %s in DB: %v", rate.BaseCurrency, rate.QuoteCurrency, err)
+		return fmt.Errorf("failed to set exchange rate from %s to %s: %w", rate.BaseCurrency, rate.QuoteCurrency, err)
+	}
+
+	log.Printf("Exchange rate from %s to %s set to %s", rate.BaseCurrency, rate.QuoteCurrency, rate.Rate)
+	return nil
+}
+
+func (s *exchangeRateStore) DeleteFromDB(ctx context.Context, baseCurrency string, quoteCurrency string) error {
+	// SQL query to delete the rate of a currency pair
+	query := `
+		DELETE FROM exchange_rates
+		WHERE base_currency = $1
+		AND quote_currency = $2
+		RETURNING base_currency
+	`
+
+	fields := []interface{}{
+		baseCurrency,
+		quoteCurrency,
+	}
+
+	var deleted string
+	if err := utils.ExecGetQuery(
+		s.db,
+		query,
+		fields,
+		&deleted,
+	); err != nil {
+		// If no rows affected (Exchange Rate Not Found)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Exchange rate from %s to %s not found", baseCurrency, quoteCurrency)
+			return fmt.Errorf("%w: from %s to %s", ErrExchangeRateNotFound, baseCurrency, quoteCurrency)
+		}
+		log.Printf("Error deleting exchange rate from %s to %s in DB: %v", baseCurrency, quoteCurrency, err)
+		return fmt.Errorf("failed to delete exchange rate from %s to %s: %w", baseCurrency, quoteCurrency, err)
+	}
+
+	log.Printf("Exchange rate from %s to %s deleted successfully", baseCurrency, quoteCurrency)
+	return nil
+}
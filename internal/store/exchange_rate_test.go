@@ -0,0 +1,244 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRateGetRateFromDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewExchangeRateStore(db)
+	defer db.Close()
+
+	rateQuery := regexp.QuoteMeta(`
+		SELECT rate
+		FROM exchange_rates
+		WHERE base_currency = $1
+		AND quote_currency = $2
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		base        string
+		quote       string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+		expectRate  string
+	}{
+		{
+			name:  "Successful fetch",
+			base:  "EUR",
+			quote: "USD",
+			mock: func() {
+				mock.ExpectQuery(rateQuery).
+					WithArgs("EUR", "USD").
+					WillReturnRows(sqlmock.NewRows([]string{"rate"}).AddRow("1.08450000"))
+			},
+			expectErr:  false,
+			expectRate: "1.0845",
+		},
+		{
+			name:       "Same currency needs no lookup",
+			base:       "USD",
+			quote:      "USD",
+			mock:       func() {},
+			expectErr:  false,
+			expectRate: "1",
+		},
+		{
+			name:  "Rate not found",
+			base:  "GBP",
+			quote: "USD",
+			mock: func() {
+				mock.ExpectQuery(rateQuery).
+					WithArgs("GBP", "USD").
+					WillReturnRows(sqlmock.NewRows([]string{"rate"}))
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrExchangeRateNotFound,
+		},
+		{
+			name:  "Query execution error",
+			base:  "EUR",
+			quote: "USD",
+			mock: func() {
+				mock.ExpectQuery(rateQuery).
+					WithArgs("EUR", "USD").
+					WillReturnError(errors.New("query error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			rate, err := s.GetRateFromDB(context.Background(), tt.base, tt.quote)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectRate, rate.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExchangeRateUpsertInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewExchangeRateStore(db)
+	defer db.Close()
+
+	upsertQuery := regexp.QuoteMeta(`
+		INSERT INTO exchange_rates (base_currency, quote_currency, rate, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (base_currency, quote_currency)
+		DO UPDATE SET rate = EXCLUDED.rate, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`)
+
+	// Create test data
+	now := time.Now()
+	newRate := func() *models.ExchangeRate {
+		return &models.ExchangeRate{
+			BaseCurrency:  "EUR",
+			QuoteCurrency: "USD",
+			Rate:          mustParseRate(t, "1.0845"),
+		}
+	}
+
+	// Write testcases
+	tests := []struct {
+		name      string
+		mock      func()
+		expectErr bool
+	}{
+		{
+			name: "Successful upsert",
+			mock: func() {
+				// The rate is written as exact decimal text
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("EUR", "USD", "1.0845").
+					WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+			},
+			expectErr: false,
+		},
+		{
+			name: "Query execution error",
+			mock: func() {
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("EUR", "USD", "1.0845").
+					WillReturnError(errors.New("query error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			rate := newRate()
+			err := s.UpsertInDB(context.Background(), rate)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, now, rate.UpdatedAt)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExchangeRateDeleteFromDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewExchangeRateStore(db)
+	defer db.Close()
+
+	deleteQuery := regexp.QuoteMeta(`
+		DELETE FROM exchange_rates
+		WHERE base_currency = $1
+		AND quote_currency = $2
+		RETURNING base_currency
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful delete",
+			mock: func() {
+				mock.ExpectQuery(deleteQuery).
+					WithArgs("EUR", "USD").
+					WillReturnRows(sqlmock.NewRows([]string{"base_currency"}).AddRow("EUR"))
+			},
+			expectErr: false,
+		},
+		{
+			name: "Rate not found",
+			mock: func() {
+				mock.ExpectQuery(deleteQuery).
+					WithArgs("EUR", "USD").
+					WillReturnRows(sqlmock.NewRows([]string{"base_currency"}))
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrExchangeRateNotFound,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.DeleteFromDB(context.Background(), "EUR", "USD")
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+type ImageStore interface {
+	GetByProductFromDB(ctx context.Context, productID string) ([]models.ProductImage, error)
+	CreateInDB(ctx context.Context, image *models.ProductImage) (string, error)
+	GetThumbnailsByProductFromDB(ctx context.Context, productID string) ([]models.ImageThumbnail, error)
+	GetMissingThumbnailsFromDB(ctx context.Context, sizes []int, contentTypes []string) ([]models.ProductImage, error)
+	SaveThumbnailInDB(ctx context.Context, thumbnail *models.ImageThumbnail) error
+}
+
+type imageStore struct {
+	db *sqlx.DB
+}
+
+func NewImageStore(db *sqlx.DB) ImageStore {
+	return &imageStore{
+		db: db,
+	}
+}
+
+func (s *imageStore) GetByProductFromDB(ctx context.Context, productID string) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+
+	// SQL query to get the images of a product in display order
+	query := `
+		SELECT image_id, product_id, storage_key, content_type, size_bytes, position, created_at
+		FROM product_images
+		WHERE product_id = $1
+		ORDER BY position
+	`
+
+	fields := []interface{}{
+		productID,
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&images,
+	); err != nil {
+		log.Printf("Error fetching images of product with ID %s from DB: %v", productID, err)
+		return nil, err
+	}
+
+	return images, nil
+}
+
+func (s *imageStore) CreateInDB(ctx context.Context, image *models.ProductImage) (string, error) {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return "", fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to lock the product so concurrent uploads get distinct positions.
+	// Archived products take no new images.
+	lockQuery := `
+		SELECT product_id
+		FROM products
+		WHERE product_id = $1
+		AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	var productID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		lockQuery,
+		[]interface{}{image.ProductID},
+		&productID,
+	)
+	if txErr != nil {
+		// If no rows found
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Product with ID %s not found", image.ProductID)
+			return "", fmt.Errorf("%w: product with ID %s", ErrProductNotFound, image.ProductID)
+		}
+		log.Printf("Error locking product with ID %s: %v", image.ProductID, txErr)
+		return "", fmt.Errorf("failed to lock product with ID %s: %w", image.ProductID, txErr)
+	}
+
+	// SQL query to add an image after the existing ones
+	query := `
+		INSERT INTO product_images (image_id, product_id, storage_key, content_type, size_bytes, position, created_at)
+		SELECT gen_random_uuid(), $1, $2, $3, $4, COALESCE(MAX(position), 0) + 1, CURRENT_TIMESTAMP
+		FROM product_images
+		WHERE product_id = $1
+		RETURNING image_id, position
+	`
+
+	fields := []interface{}{
+		image.ProductID,
+		image.StorageKey,
+		image.ContentType,
+		image.SizeBytes,
+	}
+
+	// Execute the query and return the new image ID and position
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		image,
+	)
+	if txErr != nil {
+		log.Printf("Error inserting image in DB: %v", txErr)
+		return "", fmt.Errorf("failed to add image to product with ID %s: %w", image.ProductID, txErr)
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for image with ID %s: %v", image.ImageID, txErr)
+		return "", fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Image with ID %s added to product with ID %s", image.ImageID, image.ProductID)
+	return image.ImageID, nil
+}
+
+func (s *imageStore) GetThumbnailsByProductFromDB(ctx context.Context, productID string) ([]models.ImageThumbnail, error) {
+	var thumbnails []models.ImageThumbnail
+
+	// SQL query to get the thumbnails of every image of a product, smallest first
+	query := `
+		SELECT t.image_id, t.size, t.storage_key, t.width, t.height
+		FROM product_image_thumbnails t
+		JOIN product_images i ON i.image_id = t.image_id
+		WHERE i.product_id = $1
+		ORDER BY t.image_id, t.size
+	`
+
+	fields := []interface{}{
+		productID,
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&thumbnails,
+	); err != nil {
+		log.Printf("Error fetching thumbnails of product with ID %s from DB: %v", productID, err)
+		return nil, err
+	}
+
+	return thumbnails, nil
+}
+
+func (s *imageStore) GetMissingThumbnailsFromDB(ctx context.Context, sizes []int, contentTypes []string) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+
+	// SQL query to find images of a decodable type that lack any of the given thumbnail sizes
+	query := `
+		SELECT i.image_id, i.product_id, i.storage_key, i.content_type, i.size_bytes, i.position, i.created_at
+		FROM product_images i
+		WHERE i.content_type = ANY($2)
+		AND EXISTS (
+			SELECT 1
+			FROM unnest($1::int[]) AS s(size)
+			WHERE NOT EXISTS (
+				SELECT 1
+				FROM product_image_thumbnails t
+				WHERE t.image_id = i.image_id
+				AND t.size = s.size
+			)
+		)
+		ORDER BY i.created_at
+	`
+
+	fields := []interface{}{
+		pq.Array(sizes),
+		pq.Array(contentTypes),
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&images,
+	); err != nil {
+		log.Printf("Error fetching images missing thumbnails from DB: %v", err)
+		return nil, err
+	}
+
+	return images, nil
+}
+
+func (s *imageStore) SaveThumbnailInDB(ctx context.Context, thumbnail *models.ImageThumbnail) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to record a thumbnail, replacing an earlier one of the same size
+	query := `
+		INSERT INTO product_image_thumbnails (image_id, size, storage_key, width, height, created_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (image_id, size)
+		DO UPDATE SET storage_key = EXCLUDED.storage_key, width = EXCLUDED.width, height = EXCLUDED.height,
+			created_at = CURRENT_TIMESTAMP
+	`
+
+	fields := []interface{}{
+		thumbnail.ImageID,
+		thumbnail.Size,
+		thumbnail.StorageKey,
+		thumbnail.Width,
+		thumbnail.Height,
+	}
+
+	if _, txErr = utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+	); txErr != nil {
+		log.Printf("Error saving thumbnail in DB: %v", txErr)
+		return fmt.Errorf("failed to save %dpx thumbnail of image with ID %s: %w", thumbnail.Size, thumbnail.ImageID, txErr)
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for image with ID %s: %v", thumbnail.ImageID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Thumbnail of %dpx saved for image with ID %s", thumbnail.Size, thumbnail.ImageID)
+	return nil
+}
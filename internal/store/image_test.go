@@ -0,0 +1,130 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageCreateInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewImageStore(db)
+	defer db.Close()
+
+	lockQuery := regexp.QuoteMeta(`
+		SELECT product_id
+		FROM products
+		WHERE product_id = $1
+		AND deleted_at IS NULL
+		FOR UPDATE
+	`)
+	insertQuery := regexp.QuoteMeta(`
+		INSERT INTO product_images (image_id, product_id, storage_key, content_type, size_bytes, position, created_at)
+		SELECT gen_random_uuid(), $1, $2, $3, $4, COALESCE(MAX(position), 0) + 1, CURRENT_TIMESTAMP
+	`)
+
+	// Create test data
+	newImage := func() *models.ProductImage {
+		return &models.ProductImage{
+			ProductID:   "prod-1",
+			StorageKey:  "products/prod-1/abc.png",
+			ContentType: "image/png",
+			SizeBytes:   2048,
+		}
+	}
+
+	// Write testcases
+	tests := []struct {
+		name           string
+		mock           func()
+		expectErr      bool
+		expectErrIs    error
+		expectID       string
+		expectPosition int
+	}{
+		{
+			name: "Successful insert after existing images",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).
+					WithArgs("prod-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow("prod-1"))
+
+				mock.ExpectQuery(insertQuery).
+					WithArgs("prod-1", "products/prod-1/abc.png", "image/png", int64(2048)).
+					WillReturnRows(sqlmock.NewRows([]string{"image_id", "position"}).AddRow("img-1", 3))
+
+				mock.ExpectCommit()
+			},
+			expectErr:      false,
+			expectID:       "img-1",
+			expectPosition: 3,
+		},
+		{
+			name: "Product not found or archived",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).
+					WithArgs("prod-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrProductNotFound,
+		},
+		{
+			name: "Insert error",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).
+					WithArgs("prod-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow("prod-1"))
+
+				mock.ExpectQuery(insertQuery).
+					WithArgs("prod-1", "products/prod-1/abc.png", "image/png", int64(2048)).
+					WillReturnError(errors.New("insert error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			image := newImage()
+			imageID, err := s.CreateInDB(context.Background(), image)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectPosition, image.Position)
+			}
+			assert.Equal(t, tt.expectID, imageID)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -6,18 +6,33 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/officiallysidsingh/ecom-server/internal/models"
 	"github.com/officiallysidsingh/ecom-server/internal/utils"
 )
 
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrStatusConflict    = errors.New("order status was changed by another request")
+)
+
 type OrderStore interface {
 	GetAllFromDB(ctx context.Context, userID string) ([]models.Order, error)
 	GetByIDFromDB(ctx context.Context, orderID string, userID string) (*models.Order, error)
+	GetAnyByIDFromDB(ctx context.Context, orderID string) (*models.Order, error)
+	// CreateInDB removes cartItems from the cart of the order's user in the same transaction; nil leaves the cart alone
+	CreateInDB(ctx context.Context, order *models.Order, taxRules models.TaxRules, cartItems []models.CartItem) (string, error)
+	GetStatusFromDB(ctx context.Context, orderID string) (models.OrderStatus, error)
+	UpdateStatusInDB(ctx context.Context, orderID string, from models.OrderStatus, to models.OrderStatus, changedBy string) error
+	GetStatusHistoryFromDB(ctx context.Context, orderID string) ([]models.OrderStatusChange, error)
+	CancelInDB(ctx context.Context, orderID string, userID string, from models.OrderStatus) error
 	// PutUpdateInDB(ctx context.Context, order *models.Order, orderID string) error
 	// PatchUpdateInDB(ctx context.Context, order *models.Order, orderID string) error
-	// CreateInDB(ctx context.Context, order *models.Order) (string, error)
 	// DeleteFromDB(ctx context.Context, orderID string) error
 }
 
@@ -36,7 +51,7 @@ func (s *orderStore) GetAllFromDB(ctx context.Context, userID string) ([]models.
 
 	// SQL query to get all orders
 	query := `
-		SELECT order_id, user_id, payment_method, tax_price, shipping_price, total_price, created_at, updated_at
+		SELECT order_id, user_id, status, payment_method, currency, tax_country, tax_region, tax_price, shipping_price, total_price, created_at, updated_at
 		FROM orders
 		WHERE user_id = $1
 	`
@@ -55,15 +70,20 @@ func (s *orderStore) GetAllFromDB(ctx context.Context, userID string) ([]models.
 		return nil, err
 	}
 
+	// Attach line items to every order in one query
+	if err := s.loadItems(orders); err != nil {
+		return nil, err
+	}
+
 	return orders, nil
 }
 
 func (s *orderStore) GetByIDFromDB(ctx context.Context, orderID string, userID string) (*models.Order, error) {
 	var order models.Order
 
-	// SQL query to get an order by id
+	// SQL query to get an order by id, only if it belongs to the user
 	query := `
-		SELECT order_id, user_id, payment_method, tax_price, shipping_price, total_price, created_at, updated_at
+		SELECT order_id, user_id, status, payment_method, currency, tax_country, tax_region, tax_price, shipping_price, total_price, created_at, updated_at
 		FROM orders
 		WHERE user_id = $1
 		AND order_id = $2
@@ -74,7 +94,7 @@ func (s *orderStore) GetByIDFromDB(ctx context.Context, orderID string, userID s
 		orderID,
 	}
 
-	if err := utils.ExecSelectQuery(
+	if err := utils.ExecGetQuery(
 		s.db,
 		query,
 		fields,
@@ -83,11 +103,662 @@ func (s *orderStore) GetByIDFromDB(ctx context.Context, orderID string, userID s
 		// If no rows found
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Printf("Order with userID %s and orderID %s not found", userID, orderID)
-			return nil, fmt.Errorf("order with userID %s and orderID %s not found", userID, orderID)
+			return nil, fmt.Errorf("%w: order with userID %s and orderID %s", ErrOrderNotFound, userID, orderID)
 		}
 		log.Printf("Error fetching order with userID %s and orderID %s from DB: %v", userID, orderID, err)
 		return nil, err
 	}
 
-	return &order, nil
+	// Attach line items to the order
+	orders := []models.Order{order}
+	if err := s.loadItems(orders); err != nil {
+		return nil, err
+	}
+
+	return &orders[0], nil
+}
+
+// Get an order by id whoever placed it, for staff who may read any order
+func (s *orderStore) GetAnyByIDFromDB(ctx context.Context, orderID string) (*models.Order, error) {
+	var order models.Order
+
+	// SQL query to get an order by id
+	query := `
+		SELECT order_id, user_id, status, payment_method, currency, tax_country, tax_region, tax_price, shipping_price, total_price, created_at, updated_at
+		FROM orders
+		WHERE order_id = $1
+	`
+
+	fields := []interface{}{
+		orderID,
+	}
+
+	if err := utils.ExecGetQuery(
+		s.db,
+		query,
+		fields,
+		&order,
+	); err != nil {
+		// If no rows found
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Order with ID %s not found", orderID)
+			return nil, fmt.Errorf("%w: order with ID %s", ErrOrderNotFound, orderID)
+		}
+		log.Printf("Error fetching order with ID %s from DB: %v", orderID, err)
+		return nil, err
+	}
+
+	// Attach line items to the order
+	orders := []models.Order{order}
+	if err := s.loadItems(orders); err != nil {
+		return nil, err
+	}
+
+	return &orders[0], nil
+}
+
+// Fetch the line items of all given orders in a single query and attach them in place
+func (s *orderStore) loadItems(orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	orderIDs := make([]string, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.OrderID
+	}
+
+	var items []models.OrderItem
+
+	// SQL query to get the items of several orders with their product summary
+	query := `
+		SELECT oi.order_item_id, oi.order_id, oi.product_id, oi.variant_id, v.sku, oi.quantity, oi.unit_price, oi.total_price,
+			oi.product_currency, oi.exchange_rate, oi.tax_rule_id, oi.tax_rate, oi.tax_inclusive, oi.tax_price,
+			p.name AS "product.name", p.price AS "product.price"
+		FROM order_items oi
+		JOIN products p ON p.product_id = oi.product_id
+		LEFT JOIN product_variants v ON v.variant_id = oi.variant_id
+		WHERE oi.order_id = ANY($1)
+		ORDER BY oi.order_id, p.name
+	`
+
+	fields := []interface{}{
+		pq.Array(orderIDs),
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&items,
+	); err != nil {
+		log.Printf("Error fetching order items from DB: %v", err)
+		return err
+	}
+
+	// Group items by the order they belong to
+	itemsByOrder := make(map[string][]models.OrderItem, len(orders))
+	for _, item := range items {
+		itemsByOrder[item.OrderID] = append(itemsByOrder[item.OrderID], item)
+	}
+
+	for i := range orders {
+		orders[i].Items = itemsByOrder[orders[i].OrderID]
+		if orders[i].Items == nil {
+			orders[i].Items = []models.OrderItem{}
+		}
+	}
+
+	return nil
+}
+
+func (s *orderStore) CreateInDB(ctx context.Context, order *models.Order, taxRules models.TaxRules, cartItems []models.CartItem) (string, error) {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return "", fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// Lock products in a stable order so concurrent checkouts can't deadlock
+	sort.Slice(order.Items, func(i, j int) bool {
+		if order.Items[i].ProductID != order.Items[j].ProductID {
+			return order.Items[i].ProductID < order.Items[j].ProductID
+		}
+		return variantKey(order.Items[i].VariantID) < variantKey(order.Items[j].VariantID)
+	})
+
+	// SQL query to lock a variant and its product row for the rest of the transaction,
+	// falling back to the default variant when none was picked
+	lockQuery := `
+		SELECT v.variant_id, COALESCE(v.price, p.price) AS effective_price, v.stock, p.currency, p.tax_class
+		FROM product_variants v
+		JOIN products p ON p.product_id = v.product_id
+		WHERE v.product_id = $1
+		AND p.deleted_at IS NULL
+		AND (v.variant_id::text = $2 OR ($2 = '' AND v.is_default))
+		FOR UPDATE OF p, v
+	`
+
+	// SQL query to take the ordered quantity out of the variant stock
+	stockQuery := `
+		UPDATE product_variants
+		SET stock = stock - $1, updated_at = CURRENT_TIMESTAMP
+		WHERE variant_id = $2
+	`
+
+	// SQL query to get the rate from a product currency into the order currency
+	rateQuery := `
+		SELECT rate
+		FROM exchange_rates
+		WHERE base_currency = $1
+		AND quote_currency = $2
+	`
+
+	// Each product currency is converted at one rate for the whole order
+	rates := map[string]models.Rate{order.Currency: models.UnitRate()}
+
+	var itemsPrice, taxPrice, addedTax models.Money
+	for i := range order.Items {
+		item := &order.Items[i]
+
+		var variant struct {
+			models.ProductVariant
+			Currency string `db:"currency"`
+			TaxClass string `db:"tax_class"`
+		}
+		txErr = utils.ExecGetTransactionQuery(
+			s.db,
+			tx,
+			lockQuery,
+			[]interface{}{item.ProductID, variantKey(item.VariantID)},
+			&variant,
+		)
+		if txErr != nil {
+			// If no rows found
+			if errors.Is(txErr, sql.ErrNoRows) {
+				if item.VariantID != nil {
+					log.Printf("Variant with ID %s of product with ID %s not found", *item.VariantID, item.ProductID)
+					return "", fmt.Errorf("%w: variant with ID %s of product with ID %s", ErrVariantNotFound, *item.VariantID, item.ProductID)
+				}
+				log.Printf("Product with ID %s not found", item.ProductID)
+				return "", fmt.Errorf("%w: product with ID %s", ErrProductNotFound, item.ProductID)
+			}
+			log.Printf("Error locking product with ID %s: %v", item.ProductID, txErr)
+			return "", fmt.Errorf("failed to lock product with ID %s: %w", item.ProductID, txErr)
+		}
+
+		// Reject the whole order rather than oversell
+		if variant.Stock < item.Quantity {
+			txErr = fmt.Errorf(
+				"%w: variant with ID %s of product with ID %s has %d left, %d requested",
+				ErrInsufficientStock,
+				variant.VariantID,
+				item.ProductID,
+				variant.Stock,
+				item.Quantity,
+			)
+			log.Printf("Error creating order: %v", txErr)
+			return "", txErr
+		}
+
+		rate, ok := rates[variant.Currency]
+		if !ok {
+			txErr = utils.ExecGetTransactionQuery(
+				s.db,
+				tx,
+				rateQuery,
+				[]interface{}{variant.Currency, order.Currency},
+				&rate,
+			)
+			if txErr != nil {
+				// If no rows found
+				if errors.Is(txErr, sql.ErrNoRows) {
+					log.Printf("Exchange rate from %s to %s not found", variant.Currency, order.Currency)
+					return "", fmt.Errorf("%w: from %s to %s", ErrExchangeRateNotFound, variant.Currency, order.Currency)
+				}
+				log.Printf("Error fetching exchange rate from %s to %s: %v", variant.Currency, order.Currency, txErr)
+				return "", fmt.Errorf("failed to fetch exchange rate from %s to %s: %w", variant.Currency, order.Currency, txErr)
+			}
+			rates[variant.Currency] = rate
+		}
+
+		// Price the line from the DB, never from the client, converting the unit price before multiplying
+		item.VariantID = &variant.VariantID
+		item.ProductCurrency = variant.Currency
+		item.ExchangeRate = rate
+		item.UnitPrice = variant.EffectivePrice.MulRate(rate.Rat())
+		item.TotalPrice = item.UnitPrice.Mul(item.Quantity)
+		itemsPrice += item.TotalPrice
+
+		// Tax is rounded per line; inclusive tax is already part of the line total
+		taxRules.Apply(item, variant.TaxClass)
+		taxPrice += item.TaxPrice
+		if !item.TaxInclusive {
+			addedTax += item.TaxPrice
+		}
+
+		if _, txErr = utils.ExecTransactionQuery(
+			s.db,
+			tx,
+			stockQuery,
+			[]interface{}{item.Quantity, variant.VariantID},
+		); txErr != nil {
+			log.Printf("Error updating stock for variant with ID %s: %v", variant.VariantID, txErr)
+			return "", fmt.Errorf("failed to update stock for variant with ID %s: %w", variant.VariantID, txErr)
+		}
+	}
+
+	order.TaxPrice = taxPrice
+	order.TotalPrice = itemsPrice + addedTax + order.ShippingPrice
+
+	// SQL query to insert a new order
+	orderQuery := `
+		INSERT INTO orders (order_id, user_id, payment_method, currency, tax_country, tax_region, tax_price, shipping_price, total_price, created_at, updated_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING order_id
+	`
+
+	orderFields := []interface{}{
+		order.UserID,
+		order.PaymentMethod,
+		order.Currency,
+		order.TaxCountry,
+		order.TaxRegion,
+		order.TaxPrice,
+		order.ShippingPrice,
+		order.TotalPrice,
+	}
+
+	// Execute the query and return the added order ID
+	var orderID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		orderQuery,
+		orderFields,
+		&orderID,
+	)
+	if txErr != nil {
+		log.Printf("Error adding order for user with ID %s to DB: %v", order.UserID, txErr)
+		return "", txErr
+	}
+
+	// SQL query to insert an order item
+	itemQuery := `
+		INSERT INTO order_items (order_item_id, order_id, product_id, variant_id, quantity, unit_price, total_price, product_currency, exchange_rate,
+			tax_rule_id, tax_rate, tax_inclusive, tax_price)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.OrderID = orderID
+
+		itemFields := []interface{}{
+			item.OrderID,
+			item.ProductID,
+			item.VariantID,
+			item.Quantity,
+			item.UnitPrice,
+			item.TotalPrice,
+			item.ProductCurrency,
+			item.ExchangeRate,
+			item.TaxRuleID,
+			item.TaxRate,
+			item.TaxInclusive,
+			item.TaxPrice,
+		}
+
+		if _, txErr = utils.ExecTransactionQuery(
+			s.db,
+			tx,
+			itemQuery,
+			itemFields,
+		); txErr != nil {
+			log.Printf("Error adding item for order with ID %s to DB: %v", orderID, txErr)
+			return "", txErr
+		}
+	}
+
+	// Every order starts its history as pending
+	order.Status = models.OrderStatusPending
+	if txErr = s.insertStatusHistory(tx, orderID, "", order.Status, order.UserID); txErr != nil {
+		return "", txErr
+	}
+
+	// A checkout takes exactly the ordered lines out of the cart, so the order and the cart change together
+	if txErr = s.removeCheckedOutItems(tx, order.UserID, cartItems); txErr != nil {
+		return "", txErr
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for order with ID %s: %v", orderID, txErr)
+		return "", fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success and return the added order ID
+	log.Printf("Order with ID %s added successfully", orderID)
+	order.OrderID = orderID
+	return orderID, nil
+}
+
+func (s *orderStore) GetStatusFromDB(ctx context.Context, orderID string) (models.OrderStatus, error) {
+	var status models.OrderStatus
+
+	// SQL query to get the status of an order
+	query := `
+		SELECT status
+		FROM orders
+		WHERE order_id = $1
+	`
+
+	fields := []interface{}{
+		orderID,
+	}
+
+	if err := utils.ExecGetQuery(
+		s.db,
+		query,
+		fields,
+		&status,
+	); err != nil {
+		// If no rows found
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Order with ID %s not found", orderID)
+			return "", fmt.Errorf("%w: order with ID %s", ErrOrderNotFound, orderID)
+		}
+		log.Printf("Error fetching status of order with ID %s from DB: %v", orderID, err)
+		return "", err
+	}
+
+	return status, nil
+}
+
+func (s *orderStore) UpdateStatusInDB(ctx context.Context, orderID string, from models.OrderStatus, to models.OrderStatus, changedBy string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to move an order on, only if nobody else moved it first
+	query := `
+		UPDATE orders
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $2
+		AND status = $3
+		RETURNING order_id
+	`
+
+	fields := []interface{}{
+		to,
+		orderID,
+		from,
+	}
+
+	// Execute the query and return the updated order ID
+	var updatedOrderID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&updatedOrderID,
+	)
+	if txErr != nil {
+		// If no rows affected (Status changed in the meantime)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Order with ID %s is no longer %s", orderID, from)
+			return fmt.Errorf("%w: order with ID %s is no longer %s", ErrStatusConflict, orderID, from)
+		}
+		// General error
+		log.Printf("Error updating status of order in DB: %v", txErr)
+		return fmt.Errorf("failed to update status of order with ID %s: %w", orderID, txErr)
+	}
+
+	if txErr = s.insertStatusHistory(tx, orderID, from, to, changedBy); txErr != nil {
+		return txErr
+	}
+
+	// Commit the transaction if update was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for order with ID %s: %v", orderID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Order with ID %s moved from %s to %s", updatedOrderID, from, to)
+	return nil
+}
+
+func (s *orderStore) GetStatusHistoryFromDB(ctx context.Context, orderID string) ([]models.OrderStatusChange, error) {
+	var history []models.OrderStatusChange
+
+	// SQL query to get the status history of an order
+	query := `
+		SELECT history_id, order_id, from_status, to_status, changed_by, changed_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY changed_at
+	`
+
+	fields := []interface{}{
+		orderID,
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&history,
+	); err != nil {
+		log.Printf("Error fetching status history of order with ID %s from DB: %v", orderID, err)
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (s *orderStore) CancelInDB(ctx context.Context, orderID string, userID string, from models.OrderStatus) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to cancel an order owned by the user, only if nobody moved it first
+	cancelQuery := `
+		UPDATE orders
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $2
+		AND user_id = $3
+		AND status = $4
+		RETURNING order_id
+	`
+
+	cancelFields := []interface{}{
+		models.OrderStatusCancelled,
+		orderID,
+		userID,
+		from,
+	}
+
+	// Execute the query and return the cancelled order ID
+	var cancelledOrderID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		cancelQuery,
+		cancelFields,
+		&cancelledOrderID,
+	)
+	if txErr != nil {
+		// If no rows affected (Status changed in the meantime)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Order with ID %s is no longer %s", orderID, from)
+			return fmt.Errorf("%w: order with ID %s is no longer %s", ErrStatusConflict, orderID, from)
+		}
+		// General error
+		log.Printf("Error cancelling order in DB: %v", txErr)
+		return fmt.Errorf("failed to cancel order with ID %s: %w", orderID, txErr)
+	}
+
+	// SQL query to put the ordered quantities back in stock, skipping variants that were deleted since
+	restockQuery := `
+		UPDATE product_variants v
+		SET stock = v.stock + oi.quantity, updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT variant_id, SUM(quantity) AS quantity
+			FROM order_items
+			WHERE order_id = $1 AND variant_id IS NOT NULL
+			GROUP BY variant_id
+		) oi
+		WHERE v.variant_id = oi.variant_id
+	`
+
+	if _, txErr = utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		restockQuery,
+		[]interface{}{orderID},
+	); txErr != nil {
+		log.Printf("Error restoring stock for order with ID %s: %v", orderID, txErr)
+		return fmt.Errorf("failed to restore stock for order with ID %s: %w", orderID, txErr)
+	}
+
+	if txErr = s.insertStatusHistory(tx, orderID, from, models.OrderStatusCancelled, userID); txErr != nil {
+		return txErr
+	}
+
+	// Commit the transaction if cancel was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for order with ID %s: %v", orderID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Order with ID %s cancelled successfully", cancelledOrderID)
+	return nil
+}
+
+// Delete the cart lines an order was placed from inside its transaction, failing if any of them
+// changed or disappeared since the cart was read
+func (s *orderStore) removeCheckedOutItems(tx *sqlx.Tx, userID string, cartItems []models.CartItem) error {
+	// SQL query to remove a checked out line, only if it still holds the ordered quantity
+	query := `
+		DELETE FROM cart_items
+		WHERE user_id = $1
+		AND variant_id = $2
+		AND quantity = $3
+		RETURNING variant_id
+	`
+
+	for _, item := range cartItems {
+		fields := []interface{}{
+			userID,
+			item.VariantID,
+			item.Quantity,
+		}
+
+		var removedVariantID string
+		if err := utils.ExecGetTransactionQuery(
+			s.db,
+			tx,
+			query,
+			fields,
+			&removedVariantID,
+		); err != nil {
+			// If no rows affected (Line changed since the cart was read)
+			if errors.Is(err, sql.ErrNoRows) {
+				log.Printf("Cart line with variant ID %s of user with ID %s changed during checkout", item.VariantID, userID)
+				return fmt.Errorf("%w: variant with ID %s", ErrCartChanged, item.VariantID)
+			}
+			log.Printf("Error removing checked out cart line with variant ID %s: %v", item.VariantID, err)
+			return fmt.Errorf("failed to remove checked out cart line with variant ID %s: %w", item.VariantID, err)
+		}
+	}
+
+	return nil
+}
+
+// Record a status change of an order inside an open transaction
+func (s *orderStore) insertStatusHistory(tx *sqlx.Tx, orderID string, from models.OrderStatus, to models.OrderStatus, changedBy string) error {
+	// SQL query to insert a status history entry
+	query := `
+		INSERT INTO order_status_history (history_id, order_id, from_status, to_status, changed_by, changed_at)
+		VALUES (gen_random_uuid(), $1, NULLIF($2, ''), $3, $4, CURRENT_TIMESTAMP)
+	`
+
+	fields := []interface{}{
+		orderID,
+		from,
+		to,
+		changedBy,
+	}
+
+	if _, err := utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+	); err != nil {
+		log.Printf("Error recording status history for order with ID %s: %v", orderID, err)
+		return fmt.Errorf("failed to record status history for order with ID %s: %w", orderID, err)
+	}
+
+	return nil
+}
+
+// The variant ID to look up, where an empty string selects the default variant
+func variantKey(variantID *string) string {
+	if variantID == nil {
+		return ""
+	}
+	return *variantID
 }
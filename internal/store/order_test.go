@@ -0,0 +1,814 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderGetAllFromDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewOrderStore(db)
+	defer db.Close()
+
+	ordersQuery := regexp.QuoteMeta(`
+		SELECT order_id, user_id, status, payment_method, currency, tax_country, tax_region, tax_price, shipping_price, total_price, created_at, updated_at
+		FROM orders
+		WHERE user_id = $1
+	`)
+	itemsQuery := regexp.QuoteMeta(`
+		SELECT oi.order_item_id, oi.order_id, oi.product_id, oi.variant_id, v.sku, oi.quantity, oi.unit_price, oi.total_price,
+			oi.product_currency, oi.exchange_rate, oi.tax_rule_id, oi.tax_rate, oi.tax_inclusive, oi.tax_price,
+			p.name AS "product.name", p.price AS "product.price"
+		FROM order_items oi
+		JOIN products p ON p.product_id = oi.product_id
+		LEFT JOIN product_variants v ON v.variant_id = oi.variant_id
+		WHERE oi.order_id = ANY($1)
+	`)
+
+	// Create test data
+	now := time.Now()
+	variantID, sku := "var-1", "SKU-1"
+	country, taxRuleID := "US", "rule-1"
+	orderColumns := []string{
+		"order_id",
+		"user_id",
+		"status",
+		"payment_method",
+		"currency",
+		"tax_country",
+		"tax_region",
+		"tax_price",
+		"shipping_price",
+		"total_price",
+		"created_at",
+		"updated_at",
+	}
+	itemColumns := []string{
+		"order_item_id",
+		"order_id",
+		"product_id",
+		"variant_id",
+		"sku",
+		"quantity",
+		"unit_price",
+		"total_price",
+		"product_currency",
+		"exchange_rate",
+		"tax_rule_id",
+		"tax_rate",
+		"tax_inclusive",
+		"tax_price",
+		"product.name",
+		"product.price",
+	}
+
+	// Write testcases
+	tests := []struct {
+		name         string
+		mock         func()
+		expectErr    bool
+		expectOrders []models.Order
+	}{
+		{
+			name: "Successful fetch with items",
+			mock: func() {
+				mock.ExpectQuery(ordersQuery).WithArgs("user-1").WillReturnRows(
+					sqlmock.NewRows(orderColumns).
+						AddRow("order-1", "user-1", "paid", "PayPal", "USD", "US", "CA", 1.65, 0.0, 21.65, now, now).
+						AddRow("order-2", "user-1", "pending", "PayPal", "USD", nil, "", 0.0, 0.0, 0.0, now, now),
+				)
+
+				// Items for every order come back from one batched query
+				mock.ExpectQuery(itemsQuery).
+					WithArgs(pq.Array([]string{"order-1", "order-2"})).
+					WillReturnRows(
+						sqlmock.NewRows(itemColumns).
+							AddRow("item-1", "order-1", "prod-1", "var-1", "SKU-1", 2, 10.0, 20.0, "EUR", "1.25", "rule-1", "0.0825", false, 1.65, "Mouse", 8.0),
+					)
+			},
+			expectErr: false,
+			expectOrders: []models.Order{
+				{
+					OrderID:       "order-1",
+					UserID:        "user-1",
+					Status:        models.OrderStatusPaid,
+					PaymentMethod: "PayPal",
+					Currency:      "USD",
+					TaxCountry:    &country,
+					TaxRegion:     "CA",
+					TaxPrice:      165,
+					TotalPrice:    2165,
+					CreatedAt:     now,
+					UpdatedAt:     now,
+					Items: []models.OrderItem{
+						{
+							OrderItemID:     "item-1",
+							OrderID:         "order-1",
+							ProductID:       "prod-1",
+							VariantID:       &variantID,
+							SKU:             &sku,
+							Quantity:        2,
+							UnitPrice:       1000,
+							TotalPrice:      2000,
+							ProductCurrency: "EUR",
+							ExchangeRate:    mustParseRate(t, "1.25"),
+							TaxRuleID:       &taxRuleID,
+							TaxRate:         mustParseRate(t, "0.0825"),
+							TaxPrice:        165,
+							Product: models.OrderItemProduct{
+								Name:  "Mouse",
+								Price: 800,
+							},
+						},
+					},
+				},
+				{
+					OrderID:       "order-2",
+					UserID:        "user-1",
+					Status:        models.OrderStatusPending,
+					PaymentMethod: "PayPal",
+					Currency:      "USD",
+					CreatedAt:     now,
+					UpdatedAt:     now,
+					Items:         []models.OrderItem{},
+				},
+			},
+		},
+		{
+			name: "Items query error",
+			mock: func() {
+				mock.ExpectQuery(ordersQuery).WithArgs("user-1").WillReturnRows(
+					sqlmock.NewRows(orderColumns).
+						AddRow("order-1", "user-1", "paid", "PayPal", "USD", "US", "CA", 1.65, 0.0, 21.65, now, now),
+				)
+
+				mock.ExpectQuery(itemsQuery).
+					WithArgs(pq.Array([]string{"order-1"})).
+					WillReturnError(errors.New("query error"))
+			},
+			expectErr:    true,
+			expectOrders: nil,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			orders, err := s.GetAllFromDB(context.Background(), "user-1")
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, orders)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectOrders, orders)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOrderGetByIDFromDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewOrderStore(db)
+	defer db.Close()
+
+	ownQuery := regexp.QuoteMeta(`
+		SELECT order_id, user_id, status, payment_method, currency, tax_country, tax_region, tax_price, shipping_price, total_price, created_at, updated_at
+		FROM orders
+		WHERE user_id = $1
+		AND order_id = $2
+	`)
+	anyQuery := regexp.QuoteMeta(`
+		SELECT order_id, user_id, status, payment_method, currency, tax_country, tax_region, tax_price, shipping_price, total_price, created_at, updated_at
+		FROM orders
+		WHERE order_id = $1
+	`)
+	itemsQuery := regexp.QuoteMeta(`
+		FROM order_items oi
+		JOIN products p ON p.product_id = oi.product_id
+		LEFT JOIN product_variants v ON v.variant_id = oi.variant_id
+		WHERE oi.order_id = ANY($1)
+	`)
+
+	// Create test data
+	now := time.Now()
+	orderColumns := []string{
+		"order_id",
+		"user_id",
+		"status",
+		"payment_method",
+		"currency",
+		"tax_country",
+		"tax_region",
+		"tax_price",
+		"shipping_price",
+		"total_price",
+		"created_at",
+		"updated_at",
+	}
+	orderRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(orderColumns).
+			AddRow("order-1", "user-1", "paid", "PayPal", "USD", nil, "", 0.0, 0.0, 10.0, now, now)
+	}
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		get         func() (*models.Order, error)
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Own order",
+			mock: func() {
+				mock.ExpectQuery(ownQuery).WithArgs("user-1", "order-1").WillReturnRows(orderRows())
+				mock.ExpectQuery(itemsQuery).
+					WithArgs(pq.Array([]string{"order-1"})).
+					WillReturnRows(sqlmock.NewRows([]string{"order_item_id"}))
+			},
+			get: func() (*models.Order, error) {
+				return s.GetByIDFromDB(context.Background(), "order-1", "user-1")
+			},
+			expectErr: false,
+		},
+		{
+			name: "Order of another user",
+			mock: func() {
+				mock.ExpectQuery(ownQuery).WithArgs("user-2", "order-1").WillReturnRows(sqlmock.NewRows(orderColumns))
+			},
+			get: func() (*models.Order, error) {
+				return s.GetByIDFromDB(context.Background(), "order-1", "user-2")
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrOrderNotFound,
+		},
+		{
+			name: "Empty user ID matches no order",
+			mock: func() {
+				mock.ExpectQuery(ownQuery).WithArgs("", "order-1").WillReturnRows(sqlmock.NewRows(orderColumns))
+			},
+			get: func() (*models.Order, error) {
+				return s.GetByIDFromDB(context.Background(), "order-1", "")
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrOrderNotFound,
+		},
+		{
+			name: "Any order for staff",
+			mock: func() {
+				mock.ExpectQuery(anyQuery).WithArgs("order-1").WillReturnRows(orderRows())
+				mock.ExpectQuery(itemsQuery).
+					WithArgs(pq.Array([]string{"order-1"})).
+					WillReturnRows(sqlmock.NewRows([]string{"order_item_id"}))
+			},
+			get: func() (*models.Order, error) {
+				return s.GetAnyByIDFromDB(context.Background(), "order-1")
+			},
+			expectErr: false,
+		},
+		{
+			name: "Any order not found",
+			mock: func() {
+				mock.ExpectQuery(anyQuery).WithArgs("order-1").WillReturnRows(sqlmock.NewRows(orderColumns))
+			},
+			get: func() (*models.Order, error) {
+				return s.GetAnyByIDFromDB(context.Background(), "order-1")
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrOrderNotFound,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			order, err := tt.get()
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+				assert.Nil(t, order)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "order-1", order.OrderID)
+				assert.Equal(t, "user-1", order.UserID)
+				assert.Equal(t, []models.OrderItem{}, order.Items)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOrderCreateInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewOrderStore(db)
+	defer db.Close()
+
+	lockQuery := regexp.QuoteMeta(`
+		SELECT v.variant_id, COALESCE(v.price, p.price) AS effective_price, v.stock, p.currency, p.tax_class
+		FROM product_variants v
+		JOIN products p ON p.product_id = v.product_id
+		WHERE v.product_id = $1
+		AND p.deleted_at IS NULL
+		AND (v.variant_id::text = $2 OR ($2 = '' AND v.is_default))
+		FOR UPDATE OF p, v
+	`)
+	rateQuery := regexp.QuoteMeta(`
+		SELECT rate
+		FROM exchange_rates
+		WHERE base_currency = $1
+		AND quote_currency = $2
+	`)
+	stockQuery := regexp.QuoteMeta(`
+		UPDATE product_variants
+		SET stock = stock - $1, updated_at = CURRENT_TIMESTAMP
+		WHERE variant_id = $2
+	`)
+	orderQuery := regexp.QuoteMeta(`
+		INSERT INTO orders (order_id, user_id, payment_method, currency, tax_country, tax_region, tax_price, shipping_price, total_price, created_at, updated_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING order_id
+	`)
+	itemQuery := regexp.QuoteMeta(`
+		INSERT INTO order_items (order_item_id, order_id, product_id, variant_id, quantity, unit_price, total_price, product_currency, exchange_rate,
+			tax_rule_id, tax_rate, tax_inclusive, tax_price)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`)
+	historyQuery := regexp.QuoteMeta(`
+		INSERT INTO order_status_history (history_id, order_id, from_status, to_status, changed_by, changed_at)
+		VALUES (gen_random_uuid(), $1, NULLIF($2, ''), $3, $4, CURRENT_TIMESTAMP)
+	`)
+	cartQuery := regexp.QuoteMeta(`
+		DELETE FROM cart_items
+		WHERE user_id = $1
+		AND variant_id = $2
+		AND quantity = $3
+		RETURNING variant_id
+	`)
+
+	// Create test data
+	country := "US"
+	taxRules := models.TaxRules{
+		"standard": {TaxRuleID: "rule-std", Country: "US", Region: "CA", TaxClass: "standard", Rate: mustParseRate(t, "0.0825")},
+		"reduced":  {TaxRuleID: "rule-red", Country: "US", TaxClass: "reduced", Rate: mustParseRate(t, "0.2"), Inclusive: true},
+	}
+	newOrder := func() *models.Order {
+		pickedVariantID := "var-2b"
+		return &models.Order{
+			UserID:        "user-1",
+			PaymentMethod: "Credit Card",
+			Currency:      "USD",
+			TaxCountry:    &country,
+			TaxRegion:     "CA",
+			Items: []models.OrderItem{
+				{ProductID: "prod-2", VariantID: &pickedVariantID, Quantity: 1},
+				{ProductID: "prod-1", Quantity: 2},
+			},
+		}
+	}
+
+	cartItems := []models.CartItem{
+		{ProductID: "prod-2", VariantID: "var-2b", Quantity: 1},
+		{ProductID: "prod-1", VariantID: "var-1", Quantity: 2},
+	}
+
+	// Every write of a placed order, up to the cart lines it came from
+	expectPlacedOrder := func() {
+		mock.ExpectBegin()
+
+		// Variants are locked in product_id order, the default one when none was picked
+		mock.ExpectQuery(lockQuery).WithArgs("prod-1", "").WillReturnRows(
+			sqlmock.NewRows([]string{"variant_id", "effective_price", "stock", "currency", "tax_class"}).AddRow("var-1", 10.10, 5, "USD", "standard"),
+		)
+		mock.ExpectExec(stockQuery).WithArgs(2, "var-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// A product in another currency is converted at the stored rate, rounded per unit,
+		// and taxed after conversion
+		mock.ExpectQuery(lockQuery).WithArgs("prod-2", "var-2b").WillReturnRows(
+			sqlmock.NewRows([]string{"variant_id", "effective_price", "stock", "currency", "tax_class"}).AddRow("var-2b", 99.99, 1, "EUR", "reduced"),
+		)
+		mock.ExpectQuery(rateQuery).WithArgs("EUR", "USD").WillReturnRows(
+			sqlmock.NewRows([]string{"rate"}).AddRow("1.1"),
+		)
+		mock.ExpectExec(stockQuery).WithArgs(1, "var-2b").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectQuery(orderQuery).WithArgs(
+			"user-1",
+			"Credit Card",
+			"USD",
+			"US",
+			"CA",
+			models.Money(2000),
+			models.Money(0),
+			models.Money(13186),
+		).WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow("new-order-id"))
+
+		mock.ExpectExec(itemQuery).
+			WithArgs("new-order-id", "prod-1", "var-1", 2, models.Money(1010), models.Money(2020), "USD", "1",
+				"rule-std", "0.0825", false, models.Money(167)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(itemQuery).
+			WithArgs("new-order-id", "prod-2", "var-2b", 1, models.Money(10999), models.Money(10999), "EUR", "1.1",
+				"rule-red", "0.2", true, models.Money(1833)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectExec(historyQuery).
+			WithArgs("new-order-id", "", "pending", "user-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		cartItems   []models.CartItem
+		expectErr   bool
+		expectErrIs error
+		expectID    string
+		expectTax   models.Money
+		expectTotal models.Money
+	}{
+		{
+			name: "Successful order creation",
+			mock: func() {
+				expectPlacedOrder()
+
+				mock.ExpectCommit()
+			},
+			expectErr:   false,
+			expectID:    "new-order-id",
+			expectTax:   2000,
+			expectTotal: 13186,
+		},
+		{
+			name: "Successful checkout",
+			mock: func() {
+				expectPlacedOrder()
+
+				// Checked out lines leave the cart in the order transaction
+				mock.ExpectQuery(cartQuery).
+					WithArgs("user-1", "var-2b", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"variant_id"}).AddRow("var-2b"))
+				mock.ExpectQuery(cartQuery).
+					WithArgs("user-1", "var-1", 2).
+					WillReturnRows(sqlmock.NewRows([]string{"variant_id"}).AddRow("var-1"))
+
+				mock.ExpectCommit()
+			},
+			cartItems:   cartItems,
+			expectErr:   false,
+			expectID:    "new-order-id",
+			expectTax:   2000,
+			expectTotal: 13186,
+		},
+		{
+			name: "Cart changed during checkout",
+			mock: func() {
+				expectPlacedOrder()
+
+				// A line changed since the cart was read, so the whole order is rolled back
+				mock.ExpectQuery(cartQuery).
+					WithArgs("user-1", "var-2b", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"variant_id"}))
+
+				mock.ExpectRollback()
+			},
+			cartItems:   cartItems,
+			expectErr:   true,
+			expectErrIs: store.ErrCartChanged,
+			expectID:    "",
+		},
+		{
+			name: "Missing exchange rate",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).WithArgs("prod-1", "").WillReturnRows(
+					sqlmock.NewRows([]string{"variant_id", "effective_price", "stock", "currency", "tax_class"}).AddRow("var-1", 10.10, 5, "GBP", "standard"),
+				)
+				mock.ExpectQuery(rateQuery).WithArgs("GBP", "USD").WillReturnRows(
+					sqlmock.NewRows([]string{"rate"}),
+				)
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrExchangeRateNotFound,
+			expectID:    "",
+		},
+		{
+			name: "Insufficient stock",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).WithArgs("prod-1", "").WillReturnRows(
+					sqlmock.NewRows([]string{"variant_id", "effective_price", "stock", "currency", "tax_class"}).AddRow("var-1", 10.10, 1, "USD", "standard"),
+				)
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrInsufficientStock,
+			expectID:    "",
+		},
+		{
+			name: "Product not found",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).WithArgs("prod-1", "").WillReturnRows(
+					sqlmock.NewRows([]string{"variant_id", "effective_price", "stock", "currency", "tax_class"}),
+				)
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrProductNotFound,
+			expectID:    "",
+		},
+		{
+			name: "Error starting transaction",
+			mock: func() {
+				// Simulate error when starting transaction
+				mock.ExpectBegin().WillReturnError(errors.New("failed to start transaction"))
+			},
+			expectErr: true,
+			expectID:  "",
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			order := newOrder()
+			orderID, err := s.CreateInDB(context.Background(), order, taxRules, tt.cartItems)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectTax, order.TaxPrice)
+				assert.Equal(t, tt.expectTotal, order.TotalPrice)
+			}
+			assert.Equal(t, tt.expectID, orderID)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOrderUpdateStatusInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewOrderStore(db)
+	defer db.Close()
+
+	updateQuery := regexp.QuoteMeta(`
+		UPDATE orders
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $2
+		AND status = $3
+		RETURNING order_id
+	`)
+	historyQuery := regexp.QuoteMeta(`
+		INSERT INTO order_status_history (history_id, order_id, from_status, to_status, changed_by, changed_at)
+		VALUES (gen_random_uuid(), $1, NULLIF($2, ''), $3, $4, CURRENT_TIMESTAMP)
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful status update",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(updateQuery).
+					WithArgs("shipped", "order-1", "fulfilled").
+					WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow("order-1"))
+
+				mock.ExpectExec(historyQuery).
+					WithArgs("order-1", "fulfilled", "shipped", "admin-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name: "Status changed concurrently",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(updateQuery).
+					WithArgs("shipped", "order-1", "fulfilled").
+					WillReturnRows(sqlmock.NewRows([]string{"order_id"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrStatusConflict,
+		},
+		{
+			name: "History insert error",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(updateQuery).
+					WithArgs("shipped", "order-1", "fulfilled").
+					WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow("order-1"))
+
+				mock.ExpectExec(historyQuery).
+					WithArgs("order-1", "fulfilled", "shipped", "admin-1").
+					WillReturnError(errors.New("insert error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.UpdateStatusInDB(
+				context.Background(),
+				"order-1",
+				models.OrderStatusFulfilled,
+				models.OrderStatusShipped,
+				"admin-1",
+			)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOrderCancelInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewOrderStore(db)
+	defer db.Close()
+
+	cancelQuery := regexp.QuoteMeta(`
+		UPDATE orders
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $2
+		AND user_id = $3
+		AND status = $4
+		RETURNING order_id
+	`)
+	restockQuery := regexp.QuoteMeta(`
+		UPDATE product_variants v
+		SET stock = v.stock + oi.quantity, updated_at = CURRENT_TIMESTAMP
+	`)
+	historyQuery := regexp.QuoteMeta(`
+		INSERT INTO order_status_history (history_id, order_id, from_status, to_status, changed_by, changed_at)
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful cancel restores stock",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(cancelQuery).
+					WithArgs("cancelled", "order-1", "user-1", "paid").
+					WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow("order-1"))
+
+				mock.ExpectExec(restockQuery).
+					WithArgs("order-1").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+
+				mock.ExpectExec(historyQuery).
+					WithArgs("order-1", "paid", "cancelled", "user-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name: "Order no longer cancellable",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(cancelQuery).
+					WithArgs("cancelled", "order-1", "user-1", "paid").
+					WillReturnRows(sqlmock.NewRows([]string{"order_id"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrStatusConflict,
+		},
+		{
+			name: "Restock error",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(cancelQuery).
+					WithArgs("cancelled", "order-1", "user-1", "paid").
+					WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow("order-1"))
+
+				mock.ExpectExec(restockQuery).
+					WithArgs("order-1").
+					WillReturnError(errors.New("update error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.CancelInDB(context.Background(), "order-1", "user-1", models.OrderStatusPaid)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Parse a rate the same way the store scans one, so test values compare equal
+func mustParseRate(t *testing.T, s string) models.Rate {
+	rate, err := models.ParseRate(s)
+	if err != nil {
+		t.Fatalf("Failed to parse rate %q: %v", s, err)
+	}
+	return rate
+}
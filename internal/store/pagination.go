@@ -0,0 +1,145 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+)
+
+type sortColumn struct {
+	name string
+	// Postgres type the cursor value is cast back to
+	cast string
+}
+
+// Sort fields a client may request, mapped to the column they order by
+var productSortColumns = map[string]sortColumn{
+	models.ProductSortPrice:     {name: "price", cast: "numeric"},
+	models.ProductSortCreatedAt: {name: "created_at", cast: "timestamp"},
+	models.ProductSortName:      {name: "name", cast: "text"},
+}
+
+var sortDirections = map[string]string{
+	models.SortAsc:  "ASC",
+	models.SortDesc: "DESC",
+}
+
+// Format used for TIMESTAMP values inside cursors, keeping Postgres' microsecond precision
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+// productCursor is the position after the last row of a page.
+// It carries the sort it was issued for, so it cannot be replayed against another ordering.
+type productCursor struct {
+	Sort      string `json:"s"`
+	Order     string `json:"o"`
+	Value     string `json:"v"`
+	ProductID string `json:"id"`
+}
+
+func encodeProductCursor(q models.ProductQuery, last models.Product) string {
+	cursor := productCursor{
+		Sort:      q.Sort,
+		Order:     q.Order,
+		ProductID: last.ProductID,
+	}
+	switch q.Sort {
+	case models.ProductSortPrice:
+		cursor.Value = last.Price.String()
+	case models.ProductSortCreatedAt:
+		cursor.Value = last.CreatedAt.Format(cursorTimeLayout)
+	case models.ProductSortName:
+		cursor.Value = last.Name
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeProductCursor(encoded string) (*productCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var cursor productCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if cursor.ProductID == "" {
+		return nil, fmt.Errorf("%w: missing position", ErrInvalidCursor)
+	}
+
+	return &cursor, nil
+}
+
+// searchCursor is the offset of the next page of a search, bound to the search text
+type searchCursor struct {
+	Search string `json:"q"`
+	Offset int    `json:"off"`
+}
+
+func encodeSearchCursor(search string, offset int) string {
+	data, _ := json.Marshal(searchCursor{
+		Search: search,
+		Offset: offset,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSearchCursor(encoded string) (*searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var cursor searchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if cursor.Offset < 0 {
+		return nil, fmt.Errorf("%w: negative offset", ErrInvalidCursor)
+	}
+
+	return &cursor, nil
+}
+
+// Append the price, stock and category filters of a product query to the given conditions and args.
+// Archived products never match.
+func productFilters(q models.ProductQuery, conditions []string, fields []interface{}) ([]string, []interface{}) {
+	conditions = append(conditions, "deleted_at IS NULL")
+	if q.MinPrice != nil {
+		fields = append(fields, *q.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("price >= $%d", len(fields)))
+	}
+	if q.MaxPrice != nil {
+		fields = append(fields, *q.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("price <= $%d", len(fields)))
+	}
+	if q.InStock {
+		conditions = append(conditions, "COALESCE(stock, 0) > 0")
+	}
+	if q.CategoryID != "" {
+		// Products linked to the category or any of its descendants
+		fields = append(fields, q.CategoryID)
+		conditions = append(conditions, fmt.Sprintf(`product_id IN (
+			WITH RECURSIVE subtree AS (
+				SELECT category_id FROM categories WHERE category_id = $%d
+				UNION ALL
+				SELECT c.category_id FROM categories c JOIN subtree st ON c.parent_id = st.category_id
+			)
+			SELECT pc.product_id FROM product_categories pc JOIN subtree USING (category_id)
+		)`, len(fields)))
+	}
+	return conditions, fields
+}
+
+// Join filter conditions into a WHERE clause, or nothing when there are none
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, "\n\t\tAND ") + "\n"
+}
@@ -5,20 +5,44 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"html"
 	"log"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/officiallysidsingh/ecom-server/internal/models"
 	"github.com/officiallysidsingh/ecom-server/internal/utils"
 )
 
+var (
+	ErrInvalidProductQuery = errors.New("invalid product query")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+
+	ErrProductVersionMismatch = errors.New("product has been modified since it was read")
+	ErrInvalidProductPatch    = errors.New("invalid product patch")
+	ErrStockSetOnVariants     = errors.New("stock of a product with variants is set on its variants")
+)
+
+// Search matches are delimited with control characters in SQL and only turned into <mark> tags
+// after the rest of the snippet has been HTML escaped
+var highlightMarks = strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>")
+
 type ProductStore interface {
-	GetAllFromDB(ctx context.Context) ([]models.Product, error)
+	GetAllFromDB(ctx context.Context, q models.ProductQuery) (*models.ProductPage, error)
+	SearchFromDB(ctx context.Context, q models.ProductQuery) (*models.ProductSearchPage, error)
 	GetByIDFromDB(ctx context.Context, productID string) (*models.Product, error)
 	CreateInDB(ctx context.Context, product *models.Product) (string, error)
-	PutUpdateInDB(ctx context.Context, product *models.Product, productID string) error
-	PatchUpdateInDB(ctx context.Context, product *models.Product, productID string) error
-	DeleteFromDB(ctx context.Context, productID string) error
+	// PutUpdateInDB and PatchUpdateInDB only apply while the product is still at version.
+	// PutUpdateInDB leaves the new version in product.Version; PatchUpdateInDB returns it.
+	// Products with variants besides the default one only take their current total stock.
+	PutUpdateInDB(ctx context.Context, product *models.Product, productID string, version int) error
+	PatchUpdateInDB(ctx context.Context, patch *models.ProductPatch, productID string, version int) (int, error)
+	DeleteFromDB(ctx context.Context, productID string, version int) error
+	RestoreInDB(ctx context.Context, productID string) error
+	// GetExistingExternalIDsFromDB maps each external ID already in use to the product an import would update
+	GetExistingExternalIDsFromDB(ctx context.Context, externalIDs []string) (map[string]models.ImportTarget, error)
+	ImportBatchInDB(ctx context.Context, rows []models.ProductImportRow) ([]models.ImportRowResult, error)
+	ExportFromDB(ctx context.Context, q models.ProductQuery, fn func(*models.Product) error) error
 }
 
 type productStore struct {
@@ -31,26 +55,183 @@ func NewProductStore(db *sqlx.DB) ProductStore {
 	}
 }
 
-func (s *productStore) GetAllFromDB(ctx context.Context) ([]models.Product, error) {
-	var products []models.Product
+func (s *productStore) GetAllFromDB(ctx context.Context, q models.ProductQuery) (*models.ProductPage, error) {
+	// Only allow-listed columns ever reach the SQL
+	sortColumn, ok := productSortColumns[q.Sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort field %q", ErrInvalidProductQuery, q.Sort)
+	}
+	direction, ok := sortDirections[q.Order]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort order %q", ErrInvalidProductQuery, q.Order)
+	}
+
+	// Decode the cursor up front so a bad one fails before touching the DB
+	var cursor *productCursor
+	if q.Cursor != "" {
+		var err error
+		if cursor, err = decodeProductCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+		if cursor.Sort != q.Sort || cursor.Order != q.Order {
+			return nil, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidCursor)
+		}
+	}
+
+	// Build filters with positional args
+	conditions, fields := productFilters(q, nil, nil)
+
+	// SQL query to count all products matching the filters
+	countQuery := `
+		SELECT COUNT(*)
+		FROM products
+	` + whereClause(conditions)
 
-	// SQL query to get all products
+	var total int
+	if err := utils.ExecGetQuery(
+		s.db,
+		countQuery,
+		fields,
+		&total,
+	); err != nil {
+		log.Printf("Error counting products in DB: %v", err)
+		return nil, err
+	}
+
+	// Continue after the last row of the previous page
+	if cursor != nil {
+		comparison := ">"
+		if q.Order == models.SortDesc {
+			comparison = "<"
+		}
+		fields = append(fields, cursor.Value, cursor.ProductID)
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s, product_id) %s ($%d::%s, $%d::uuid)",
+			sortColumn.name, comparison, len(fields)-1, sortColumn.cast, len(fields),
+		))
+	}
+
+	// SQL query to get one page of products, fetching one extra row to detect a next page
 	query := `
-		SELECT product_id, name, description, price, stock, created_at, updated_at
+		SELECT product_id, name, description, price, currency, tax_class, stock, created_at, updated_at
 		FROM products
-	`
+	` + whereClause(conditions) + fmt.Sprintf(`
+		ORDER BY %s %s, product_id %s
+		LIMIT %d
+	`, sortColumn.name, direction, direction, q.Limit+1)
+	if cursor == nil && q.Offset > 0 {
+		query += fmt.Sprintf("OFFSET %d\n", q.Offset)
+	}
 
+	var products []models.Product
 	if err := utils.ExecSelectQuery(
 		s.db,
 		query,
-		nil,
+		fields,
 		&products,
 	); err != nil {
 		log.Printf("Error fetching products from DB: %v", err)
 		return nil, err
 	}
 
-	return products, nil
+	page := models.ProductPage{
+		Items: []models.Product{},
+		Pagination: models.Pagination{
+			Total:  total,
+			Limit:  q.Limit,
+			Offset: q.Offset,
+		},
+	}
+	if len(products) > q.Limit {
+		products = products[:q.Limit]
+		page.Pagination.NextCursor = encodeProductCursor(q, products[len(products)-1])
+	}
+	page.Items = append(page.Items, products...)
+
+	return &page, nil
+}
+
+func (s *productStore) SearchFromDB(ctx context.Context, q models.ProductQuery) (*models.ProductSearchPage, error) {
+	// Ranked results have no stable keyset, so search cursors carry an offset
+	offset := q.Offset
+	if q.Cursor != "" {
+		cursor, err := decodeSearchCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Search != q.Search {
+			return nil, fmt.Errorf("%w: cursor was issued for a different search", ErrInvalidCursor)
+		}
+		offset = cursor.Offset
+	}
+
+	// Match against the generated search_vector column, plus the listing filters
+	conditions, fields := productFilters(q, []string{"search_vector @@ query"}, []interface{}{q.Search})
+
+	// SQL query to count all products matching the search
+	countQuery := `
+		SELECT COUNT(*)
+		FROM products, websearch_to_tsquery('english', $1) query
+	` + whereClause(conditions)
+
+	var total int
+	if err := utils.ExecGetQuery(
+		s.db,
+		countQuery,
+		fields,
+		&total,
+	); err != nil {
+		log.Printf("Error counting products matching %q in DB: %v", q.Search, err)
+		return nil, err
+	}
+
+	// SQL query to rank one page of matches, then highlight only the rows on that page.
+	// Product text is stripped of the delimiters so it can't forge a match.
+	query := `
+		SELECT product_id, name, description, price, currency, tax_class, stock, created_at, updated_at, rank,
+			ts_headline('english', translate(name || ' ' || COALESCE(description, ''), E'\x02\x03', ''), query,
+				E'StartSel=\x02, StopSel=\x03, MaxFragments=2, MaxWords=20, MinWords=5') AS highlight
+		FROM (
+			SELECT product_id, name, description, price, currency, tax_class, stock, created_at, updated_at, query,
+				ts_rank(search_vector, query) AS rank
+			FROM products, websearch_to_tsquery('english', $1) query
+	` + whereClause(conditions) + fmt.Sprintf(`
+			ORDER BY rank DESC, product_id
+			LIMIT %d
+			OFFSET %d
+		) ranked
+		ORDER BY rank DESC, product_id
+	`, q.Limit+1, offset)
+
+	var results []models.ProductSearchResult
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&results,
+	); err != nil {
+		log.Printf("Error searching products matching %q in DB: %v", q.Search, err)
+		return nil, err
+	}
+
+	page := models.ProductSearchPage{
+		Items: []models.ProductSearchResult{},
+		Pagination: models.Pagination{
+			Total:  total,
+			Limit:  q.Limit,
+			Offset: offset,
+		},
+	}
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+		page.Pagination.NextCursor = encodeSearchCursor(q.Search, offset+q.Limit)
+	}
+	for i := range results {
+		results[i].Highlight = highlightMarks.Replace(html.EscapeString(results[i].Highlight))
+	}
+	page.Items = append(page.Items, results...)
+
+	return &page, nil
 }
 
 func (s *productStore) GetByIDFromDB(ctx context.Context, productID string) (*models.Product, error) {
@@ -58,9 +239,10 @@ func (s *productStore) GetByIDFromDB(ctx context.Context, productID string) (*mo
 
 	// SQL query to get a product by id
 	query := `
-		SELECT product_id, name, description, price, stock, created_at, updated_at
+		SELECT product_id, name, description, price, currency, tax_class, stock, created_at, updated_at, version
 		FROM products
 		WHERE product_id = $1
+		AND deleted_at IS NULL
 	`
 
 	fields := []interface{}{
@@ -76,7 +258,7 @@ func (s *productStore) GetByIDFromDB(ctx context.Context, productID string) (*mo
 		// If no rows found
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Printf("Product with ID %s not found", productID)
-			return nil, fmt.Errorf("product with ID %s not found", productID)
+			return nil, fmt.Errorf("%w: product with ID %s", ErrProductNotFound, productID)
 		}
 		log.Printf("Error fetching product with ID %s from DB: %v", productID, err)
 		return nil, err
@@ -107,8 +289,8 @@ func (s *productStore) CreateInDB(ctx context.Context, product *models.Product)
 
 	// SQL query to insert a new product
 	query := `
-		INSERT INTO products (product_id, name, description, price, stock, created_at, updated_at)
-		VALUES (gen_random_uuid(), $1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		INSERT INTO products (product_id, name, description, price, currency, tax_class, stock, created_at, updated_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		RETURNING product_id
 	`
 
@@ -116,6 +298,8 @@ func (s *productStore) CreateInDB(ctx context.Context, product *models.Product)
 		product.Name,
 		product.Description,
 		product.Price,
+		product.Currency,
+		product.TaxClass,
 		product.Stock,
 	}
 
@@ -133,6 +317,11 @@ func (s *productStore) CreateInDB(ctx context.Context, product *models.Product)
 		return "", txErr
 	}
 
+	// The default variant holds the stock of the new product
+	if txErr = s.insertDefaultVariant(tx, productID, product.Stock); txErr != nil {
+		return "", txErr
+	}
+
 	// Commit the transaction if update was successful
 	txErr = tx.Commit()
 	if txErr != nil {
@@ -145,7 +334,7 @@ func (s *productStore) CreateInDB(ctx context.Context, product *models.Product)
 	return productID, nil
 }
 
-func (s *productStore) PutUpdateInDB(ctx context.Context, product *models.Product, productID string) error {
+func (s *productStore) PutUpdateInDB(ctx context.Context, product *models.Product, productID string, version int) error {
 	// Begin a transaction to ensure atomicity
 	tx, err := s.db.Beginx()
 	if err != nil {
@@ -169,39 +358,53 @@ func (s *productStore) PutUpdateInDB(ctx context.Context, product *models.Produc
 	// SQL query to update a product
 	query := `
 		UPDATE PRODUCTS
-		SET name=$1, description=$2, price=$3, stock=$4, updated_at = CURRENT_TIMESTAMP
-		WHERE product_id = $5
-		RETURNING product_id
+		SET name=$1, description=$2, price=$3, currency=$4, tax_class=$5, stock=$6, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = $7
+		AND deleted_at IS NULL
+		AND version = $8
+		RETURNING version
 	`
 
 	fields := []interface{}{
 		product.Name,
 		product.Description,
 		product.Price,
+		product.Currency,
+		product.TaxClass,
 		product.Stock,
 		productID,
+		version,
 	}
 
-	// Execute the query and return the updated product ID
-	var updatedProductID string
+	// Execute the query and return the new product version
 	txErr = utils.ExecGetTransactionQuery(
 		s.db,
 		tx,
 		query,
 		fields,
-		&updatedProductID,
+		&product.Version,
 	)
 	if txErr != nil {
-		// If no rows affected (Product Not Found)
+		// If no rows affected (Product changed or archived since it was read)
 		if errors.Is(txErr, sql.ErrNoRows) {
-			log.Printf("Product with ID %s not found", productID)
-			return fmt.Errorf("product with ID %s not found", productID)
+			log.Printf("Product with ID %s is no longer at version %d", productID, version)
+			return fmt.Errorf("%w: product with ID %s", ErrProductVersionMismatch, productID)
 		}
 		// General error
 		log.Printf("Error updating product in DB: %v", txErr)
 		return fmt.Errorf("failed to update product with ID %s: %w", productID, txErr)
 	}
 
+	// Product level stock edits apply to the default variant
+	if txErr = s.updateDefaultVariantStock(tx, productID, product.Stock); txErr != nil {
+		return txErr
+	}
+
+	// The stock trigger bumps the version again, so the ETag has to come from after it ran
+	if product.Version, txErr = s.getVersionInTx(tx, productID); txErr != nil {
+		return txErr
+	}
+
 	// Commit the transaction if update was successful
 	txErr = tx.Commit()
 	if txErr != nil {
@@ -210,16 +413,22 @@ func (s *productStore) PutUpdateInDB(ctx context.Context, product *models.Produc
 	}
 
 	// Log the success and return the updated product ID
-	log.Printf("Product with ID %s updated successfully", updatedProductID)
+	log.Printf("Product with ID %s updated successfully to version %d", productID, product.Version)
 	return nil
 }
 
-func (s *productStore) PatchUpdateInDB(ctx context.Context, product *models.Product, productID string) error {
+func (s *productStore) PatchUpdateInDB(ctx context.Context, patch *models.ProductPatch, productID string, version int) (int, error) {
+	// Only the fields present in the patch are written
+	assignments, fields := productPatchAssignments(patch)
+	if len(assignments) == 0 {
+		return 0, fmt.Errorf("%w: patch sets no fields", ErrInvalidProductPatch)
+	}
+
 	// Begin a transaction to ensure atomicity
 	tx, err := s.db.Beginx()
 	if err != nil {
 		log.Printf("Error starting transaction: %v", err)
-		return fmt.Errorf("failed to start database transaction: %w", err)
+		return 0, fmt.Errorf("failed to start database transaction: %w", err)
 	}
 
 	// For error handling in the deferred rollback
@@ -235,62 +444,95 @@ func (s *productStore) PatchUpdateInDB(ctx context.Context, product *models.Prod
 		}
 	}()
 
-	// SQL query to update a product using COALESCE
-	query := `
+	// SQL query to update the patched fields of a product
+	fields = append(fields, productID, version)
+	query := fmt.Sprintf(`
 		UPDATE PRODUCTS
-		SET
-			name = COALESCE(NULLIF($1, ''), name),
-			description = COALESCE(NULLIF($2, ''), description),
-			price = COALESCE(NULLIF($3, 0), price),
-			stock = COALESCE(NULLIF($4, 0), stock),
-			updated_at = CURRENT_TIMESTAMP
-		WHERE product_id = $5
-		RETURNING product_id
-	`
-
-	fields := []interface{}{
-		product.Name,
-		product.Description,
-		product.Price,
-		product.Stock,
-		productID,
-	}
-
-	// Execute the query and return the updated product ID
-	var updatedProductID string
+		SET %s, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = $%d
+		AND deleted_at IS NULL
+		AND version = $%d
+		RETURNING version
+	`, strings.Join(assignments, ", "), len(fields)-1, len(fields))
+
+	// Execute the query and return the new product version
+	var newVersion int
 	txErr = utils.ExecGetTransactionQuery(
 		s.db,
 		tx,
 		query,
 		fields,
-		&updatedProductID,
+		&newVersion,
 	)
 
-	// If no rows affected (Product Not Found)
+	// If no rows affected (Product changed or archived since it was read)
 	if errors.Is(txErr, sql.ErrNoRows) {
-		log.Printf("Product with ID %s not found", productID)
-		return fmt.Errorf("product with ID %s not found", productID)
+		log.Printf("Product with ID %s is no longer at version %d", productID, version)
+		return 0, fmt.Errorf("%w: product with ID %s", ErrProductVersionMismatch, productID)
 	}
 
 	// General error handling if the query failed
 	if txErr != nil {
 		log.Printf("Error updating product in DB: %v", txErr)
-		return fmt.Errorf("failed to update product with ID %s: %w", productID, txErr)
+		return 0, fmt.Errorf("failed to update product with ID %s: %w", productID, txErr)
+	}
+
+	// Product level stock edits apply to the default variant
+	if patch.Stock != nil {
+		if txErr = s.updateDefaultVariantStock(tx, productID, *patch.Stock); txErr != nil {
+			return 0, txErr
+		}
+
+		// The stock trigger bumps the version again, so the ETag has to come from after it ran
+		if newVersion, txErr = s.getVersionInTx(tx, productID); txErr != nil {
+			return 0, txErr
+		}
 	}
 
 	// Commit the transaction if update was successful
 	txErr = tx.Commit()
 	if txErr != nil {
 		log.Printf("Error committing transaction for product with ID %s: %v", productID, txErr)
-		return fmt.Errorf("failed to commit transaction: %w", txErr)
+		return 0, fmt.Errorf("failed to commit transaction: %w", txErr)
 	}
 
-	// Log the success and return the updated product ID
-	log.Printf("Product with ID %s updated successfully", updatedProductID)
-	return nil
+	// Log the success and return the new product version
+	log.Printf("Product with ID %s updated successfully to version %d", productID, newVersion)
+	return newVersion, nil
 }
 
-func (s *productStore) DeleteFromDB(ctx context.Context, productID string) error {
+// Build the SET assignments and args for the fields present in a product patch
+func productPatchAssignments(patch *models.ProductPatch) ([]string, []interface{}) {
+	var assignments []string
+	var fields []interface{}
+	if patch.Name != nil {
+		fields = append(fields, *patch.Name)
+		assignments = append(assignments, fmt.Sprintf("name = $%d", len(fields)))
+	}
+	if patch.Description != nil {
+		fields = append(fields, *patch.Description)
+		assignments = append(assignments, fmt.Sprintf("description = $%d", len(fields)))
+	}
+	if patch.Price != nil {
+		fields = append(fields, *patch.Price)
+		assignments = append(assignments, fmt.Sprintf("price = $%d", len(fields)))
+	}
+	if patch.Currency != nil {
+		fields = append(fields, *patch.Currency)
+		assignments = append(assignments, fmt.Sprintf("currency = $%d", len(fields)))
+	}
+	if patch.TaxClass != nil {
+		fields = append(fields, *patch.TaxClass)
+		assignments = append(assignments, fmt.Sprintf("tax_class = $%d", len(fields)))
+	}
+	if patch.Stock != nil {
+		fields = append(fields, *patch.Stock)
+		assignments = append(assignments, fmt.Sprintf("stock = $%d", len(fields)))
+	}
+	return assignments, fields
+}
+
+func (s *productStore) DeleteFromDB(ctx context.Context, productID string, version int) error {
 	// Begin a transaction to ensure atomicity
 	tx, err := s.db.Beginx()
 	if err != nil {
@@ -311,17 +553,21 @@ func (s *productStore) DeleteFromDB(ctx context.Context, productID string) error
 		}
 	}()
 
-	// SQL query to delete a product
+	// SQL query to archive a product, keeping it for the orders that reference it
 	query := `
-		DELETE FROM products 
+		UPDATE products
+		SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 		WHERE product_id = $1
+		AND deleted_at IS NULL
+		AND version = $2
 		RETURNING product_id
 	`
 	fields := []interface{}{
 		productID,
+		version,
 	}
 
-	// Execute the query and return the deleted product ID
+	// Execute the query and return the archived product ID
 	var deletedProductID string
 	txErr = utils.ExecGetTransactionQuery(
 		s.db,
@@ -332,16 +578,32 @@ func (s *productStore) DeleteFromDB(ctx context.Context, productID string) error
 	)
 
 	if txErr != nil {
-		// If no rows affected (Product Not Found)
+		// If no rows affected (Product changed or archived since it was read)
 		if errors.Is(txErr, sql.ErrNoRows) {
-			log.Printf("Product with ID %s not found", productID)
-			return fmt.Errorf("product with ID %s not found", productID)
+			log.Printf("Product with ID %s is no longer at version %d", productID, version)
+			return fmt.Errorf("%w: product with ID %s", ErrProductVersionMismatch, productID)
 		}
 		// General error
 		log.Printf("Error deleting product in DB: %v", txErr)
 		return fmt.Errorf("failed to delete product with ID %s: %w", productID, txErr)
 	}
 
+	// SQL query to take the archived product out of every cart
+	cartQuery := `
+		DELETE FROM cart_items
+		WHERE product_id = $1
+	`
+
+	if _, txErr = utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		cartQuery,
+		[]interface{}{productID},
+	); txErr != nil {
+		log.Printf("Error removing product with ID %s from carts in DB: %v", productID, txErr)
+		return fmt.Errorf("failed to delete product with ID %s: %w", productID, txErr)
+	}
+
 	// Commit the transaction if delete was successful
 	txErr = tx.Commit()
 	if txErr != nil {
@@ -353,3 +615,168 @@ func (s *productStore) DeleteFromDB(ctx context.Context, productID string) error
 	log.Printf("Product with ID %s deleted successfully", deletedProductID)
 	return nil
 }
+
+func (s *productStore) RestoreInDB(ctx context.Context, productID string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to bring an archived product back
+	query := `
+		UPDATE products
+		SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = $1
+		AND deleted_at IS NOT NULL
+		RETURNING product_id
+	`
+
+	fields := []interface{}{
+		productID,
+	}
+
+	// Execute the query and return the restored product ID
+	var restoredProductID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&restoredProductID,
+	)
+	if txErr != nil {
+		// If no rows affected (Archived Product Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Archived product with ID %s not found", productID)
+			return fmt.Errorf("%w: no archived product with ID %s", ErrProductNotFound, productID)
+		}
+		// General error
+		log.Printf("Error restoring product in DB: %v", txErr)
+		return fmt.Errorf("failed to restore product with ID %s: %w", productID, txErr)
+	}
+
+	// Commit the transaction if restore was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for product with ID %s: %v", productID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Product with ID %s restored successfully", restoredProductID)
+	return nil
+}
+
+// Give a new product its default variant; the stock trigger then updates products.stock
+func (s *productStore) insertDefaultVariant(tx *sqlx.Tx, productID string, stock int) error {
+	// SQL query to add the default variant
+	query := `
+		INSERT INTO product_variants (variant_id, product_id, sku, options, price, stock, is_default, created_at, updated_at)
+		VALUES (gen_random_uuid(), $1, 'SKU-' || UPPER(LEFT(REPLACE($1::text, '-', ''), 12)), '{}', NULL, $2, TRUE, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`
+
+	if _, err := utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{productID, stock},
+	); err != nil {
+		log.Printf("Error adding default variant for product with ID %s to DB: %v", productID, err)
+		return err
+	}
+
+	return nil
+}
+
+// Set the stock of the default variant of a product; the stock trigger then updates products.stock.
+// A product with other variants has no single variant to put the stock on, so it only accepts its
+// current total and anything else fails with ErrStockSetOnVariants.
+func (s *productStore) updateDefaultVariantStock(tx *sqlx.Tx, productID string, stock int) error {
+	// SQL query to get the total stock and the number of non-default variants of a product
+	query := `
+		SELECT COALESCE(SUM(stock), 0) AS stock, COUNT(*) FILTER (WHERE NOT is_default) AS variants
+		FROM product_variants
+		WHERE product_id = $1
+	`
+
+	var current struct {
+		Stock    int `db:"stock"`
+		Variants int `db:"variants"`
+	}
+	if err := utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{productID},
+		&current,
+	); err != nil {
+		log.Printf("Error fetching variant stock for product with ID %s: %v", productID, err)
+		return fmt.Errorf("failed to update stock for product with ID %s: %w", productID, err)
+	}
+	if current.Variants > 0 {
+		if stock != current.Stock {
+			log.Printf("Product with ID %s has %d variants besides the default one, not setting its stock", productID, current.Variants)
+			return fmt.Errorf("%w: product with ID %s has %d variants besides the default one", ErrStockSetOnVariants, productID, current.Variants)
+		}
+		return nil
+	}
+
+	// SQL query to update the default variant stock
+	query = `
+		UPDATE product_variants
+		SET stock = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = $2
+		AND is_default
+	`
+
+	if _, err := utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{stock, productID},
+	); err != nil {
+		log.Printf("Error updating default variant stock for product with ID %s: %v", productID, err)
+		return fmt.Errorf("failed to update stock for product with ID %s: %w", productID, err)
+	}
+
+	return nil
+}
+
+// Read the current version of a product inside an open transaction, after its triggers have run
+func (s *productStore) getVersionInTx(tx *sqlx.Tx, productID string) (int, error) {
+	// SQL query to get the version of a product
+	query := `
+		SELECT version
+		FROM products
+		WHERE product_id = $1
+	`
+
+	var version int
+	if err := utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{productID},
+		&version,
+	); err != nil {
+		log.Printf("Error fetching version of product with ID %s: %v", productID, err)
+		return 0, fmt.Errorf("failed to fetch version of product with ID %s: %w", productID, err)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+)
+
+// ExportFromDB streams every product matching the filters to fn, one row at a time,
+// stopping at the first error fn returns
+func (s *productStore) ExportFromDB(ctx context.Context, q models.ProductQuery, fn func(*models.Product) error) error {
+	// Only allow-listed columns ever reach the SQL
+	sortColumn, ok := productSortColumns[q.Sort]
+	if !ok {
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidProductQuery, q.Sort)
+	}
+	direction, ok := sortDirections[q.Order]
+	if !ok {
+		return fmt.Errorf("%w: unknown sort order %q", ErrInvalidProductQuery, q.Order)
+	}
+
+	// Build filters with positional args
+	conditions, fields := productFilters(q, nil, nil)
+
+	// SQL query to get every matching product in a stable order
+	query := `
+		SELECT product_id, name, description, price, currency, stock, created_at, updated_at
+		FROM products
+	` + whereClause(conditions) + fmt.Sprintf(`
+		ORDER BY %s %s, product_id %s
+	`, sortColumn.name, direction, direction)
+
+	// The context ends the query when the client goes away mid-export
+	rows, err := s.db.QueryxContext(ctx, query, fields...)
+	if err != nil {
+		log.Printf("Error exporting products from DB: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var product models.Product
+		if err := rows.StructScan(&product); err != nil {
+			log.Printf("Error reading exported product from DB: %v", err)
+			return err
+		}
+		if err := fn(&product); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error exporting products from DB: %v", err)
+		return err
+	}
+
+	// Log the success
+	log.Printf("Exported %d products", count)
+	return nil
+}
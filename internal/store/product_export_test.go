@@ -0,0 +1,99 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportFromDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewProductStore(db)
+	defer db.Close()
+
+	exportQuery := regexp.QuoteMeta(`
+		SELECT product_id, name, description, price, currency, stock, created_at, updated_at
+		FROM products
+		WHERE deleted_at IS NULL AND price >= $1 AND COALESCE(stock, 0) > 0
+		ORDER BY price ASC, product_id ASC
+	`)
+
+	// Create test data
+	now := time.Now()
+	minPrice := models.Money(500)
+	query := models.ProductQuery{Sort: models.ProductSortPrice, Order: models.SortAsc, MinPrice: &minPrice, InStock: true}
+	columns := []string{"product_id", "name", "description", "price", "stock", "created_at", "updated_at"}
+	errStop := errors.New("client went away")
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		stopAfter   int
+		expectErrIs error
+		expectIDs   []string
+	}{
+		{
+			name: "Streams every row in order",
+			mock: func() {
+				mock.ExpectQuery(exportQuery).
+					WithArgs(minPrice).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow("prod-1", "Mouse", "Wireless", 9.99, 3, now, now).
+						AddRow("prod-2", "Keyboard", "Mechanical", 49.5, 1, now, now))
+			},
+			expectIDs: []string{"prod-1", "prod-2"},
+		},
+		{
+			name: "Stops when the callback fails",
+			mock: func() {
+				mock.ExpectQuery(exportQuery).
+					WithArgs(minPrice).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow("prod-1", "Mouse", "Wireless", 9.99, 3, now, now).
+						AddRow("prod-2", "Keyboard", "Mechanical", 49.5, 1, now, now))
+			},
+			stopAfter:   1,
+			expectErrIs: errStop,
+			expectIDs:   []string{"prod-1"},
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			var ids []string
+			err := s.ExportFromDB(context.Background(), query, func(product *models.Product) error {
+				ids = append(ids, product.ProductID)
+				if tt.stopAfter > 0 && len(ids) == tt.stopAfter {
+					return errStop
+				}
+				return nil
+			})
+
+			if tt.expectErrIs != nil {
+				assert.ErrorIs(t, err, tt.expectErrIs)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectIDs, ids)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
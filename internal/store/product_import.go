@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+func (s *productStore) GetExistingExternalIDsFromDB(ctx context.Context, externalIDs []string) (map[string]models.ImportTarget, error) {
+	var existing []struct {
+		ExternalID  string `db:"external_id"`
+		Archived    bool   `db:"archived"`
+		HasVariants bool   `db:"has_variants"`
+		Stock       int    `db:"stock"`
+	}
+
+	// SQL query to find which external IDs already belong to a product, archived or not
+	query := `
+		SELECT p.external_id, p.deleted_at IS NOT NULL AS archived, p.stock,
+			EXISTS (
+				SELECT 1
+				FROM product_variants v
+				WHERE v.product_id = p.product_id
+				AND NOT v.is_default
+			) AS has_variants
+		FROM products p
+		WHERE p.external_id = ANY($1)
+	`
+
+	fields := []interface{}{
+		pq.Array(externalIDs),
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&existing,
+	); err != nil {
+		log.Printf("Error fetching external IDs from DB: %v", err)
+		return nil, err
+	}
+
+	targets := make(map[string]models.ImportTarget, len(existing))
+	for _, product := range existing {
+		targets[product.ExternalID] = models.ImportTarget{
+			Archived:    product.Archived,
+			HasVariants: product.HasVariants,
+			Stock:       product.Stock,
+		}
+	}
+
+	return targets, nil
+}
+
+// ImportBatchInDB upserts a batch of rows by external ID in a single transaction,
+// so either every row of the batch is applied or none is
+func (s *productStore) ImportBatchInDB(ctx context.Context, rows []models.ProductImportRow) ([]models.ImportRowResult, error) {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to insert a product or update the one with the same external ID.
+	// Archived products, and products with variants whose total stock differs, are left alone and return no row.
+	// xmax is only zero for freshly inserted rows.
+	query := `
+		INSERT INTO products (product_id, external_id, name, description, price, currency, tax_class, stock, created_at, updated_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (external_id)
+		DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description, price = EXCLUDED.price,
+			currency = EXCLUDED.currency, tax_class = EXCLUDED.tax_class, updated_at = CURRENT_TIMESTAMP
+		WHERE products.deleted_at IS NULL
+		AND (
+			products.stock = EXCLUDED.stock
+			OR NOT EXISTS (
+				SELECT 1
+				FROM product_variants v
+				WHERE v.product_id = products.product_id
+				AND NOT v.is_default
+			)
+		)
+		RETURNING product_id, (xmax = 0) AS inserted
+	`
+
+	results := make([]models.ImportRowResult, 0, len(rows))
+	for _, row := range rows {
+		fields := []interface{}{
+			row.ExternalID,
+			row.Name,
+			row.Description,
+			row.Price,
+			row.Currency,
+			row.TaxClass,
+			row.Stock,
+		}
+
+		var upserted struct {
+			ProductID string `db:"product_id"`
+			Inserted  bool   `db:"inserted"`
+		}
+		txErr = utils.ExecGetTransactionQuery(
+			s.db,
+			tx,
+			query,
+			fields,
+			&upserted,
+		)
+		// If no rows returned (Product archived or its stock set on variants), only this row fails
+		if errors.Is(txErr, sql.ErrNoRows) {
+			var archived bool
+			if archived, txErr = s.isArchivedInTx(tx, row.ExternalID); txErr != nil {
+				return nil, fmt.Errorf("failed to import product with external ID %s on line %d: %w", row.ExternalID, row.Line, txErr)
+			}
+			skipErr := fmt.Errorf("%w: product with external ID %s has variants, so only its current total stock can be imported", ErrStockSetOnVariants, row.ExternalID)
+			if archived {
+				skipErr = fmt.Errorf("%w: product with external ID %s is archived", ErrProductNotFound, row.ExternalID)
+			}
+			log.Printf("Product with external ID %s (line %d) not imported: %v", row.ExternalID, row.Line, skipErr)
+			results = append(results, models.ImportRowResult{
+				Line:       row.Line,
+				ExternalID: row.ExternalID,
+				Action:     models.ImportActionFailed,
+				Error:      skipErr.Error(),
+			})
+			continue
+		}
+		if txErr != nil {
+			log.Printf("Error importing product with external ID %s (line %d): %v", row.ExternalID, row.Line, txErr)
+			return nil, fmt.Errorf("failed to import product with external ID %s on line %d: %w", row.ExternalID, row.Line, txErr)
+		}
+
+		// Stock lives on the default variant
+		result := models.ImportRowResult{
+			Line:       row.Line,
+			ExternalID: row.ExternalID,
+			ProductID:  upserted.ProductID,
+		}
+		if upserted.Inserted {
+			result.Action = models.ImportActionCreated
+			txErr = s.insertDefaultVariant(tx, upserted.ProductID, row.Stock)
+		} else {
+			result.Action = models.ImportActionUpdated
+			txErr = s.updateDefaultVariantStock(tx, upserted.ProductID, row.Stock)
+		}
+		if txErr != nil {
+			return nil, fmt.Errorf("failed to import product with external ID %s on line %d: %w", row.ExternalID, row.Line, txErr)
+		}
+
+		results = append(results, result)
+	}
+
+	// Commit the transaction if every row was applied
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing import batch: %v", txErr)
+		return nil, fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Imported batch of %d products", len(results))
+	return results, nil
+}
+
+// Check whether the product with an external ID is archived, inside an open transaction
+func (s *productStore) isArchivedInTx(tx *sqlx.Tx, externalID string) (bool, error) {
+	// SQL query to check whether the product is archived
+	query := `
+		SELECT deleted_at IS NOT NULL
+		FROM products
+		WHERE external_id = $1
+	`
+
+	var archived bool
+	if err := utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{externalID},
+		&archived,
+	); err != nil {
+		log.Printf("Error fetching product with external ID %s: %v", externalID, err)
+		return false, err
+	}
+
+	return archived, nil
+}
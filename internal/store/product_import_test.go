@@ -0,0 +1,199 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportBatchInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewProductStore(db)
+	defer db.Close()
+
+	upsertQuery := regexp.QuoteMeta(`
+		INSERT INTO products (product_id, external_id, name, description, price, currency, tax_class, stock, created_at, updated_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (external_id)
+		DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description, price = EXCLUDED.price,
+			currency = EXCLUDED.currency, tax_class = EXCLUDED.tax_class, updated_at = CURRENT_TIMESTAMP
+		WHERE products.deleted_at IS NULL
+		AND (
+			products.stock = EXCLUDED.stock
+			OR NOT EXISTS (
+	`)
+	insertVariantQuery := regexp.QuoteMeta(`
+		INSERT INTO product_variants (variant_id, product_id, sku, options, price, stock, is_default, created_at, updated_at)
+	`)
+	variantStockQuery := regexp.QuoteMeta(`
+		SELECT COALESCE(SUM(stock), 0) AS stock, COUNT(*) FILTER (WHERE NOT is_default) AS variants
+		FROM product_variants
+		WHERE product_id = $1
+	`)
+	archivedQuery := regexp.QuoteMeta(`
+		SELECT deleted_at IS NOT NULL
+		FROM products
+		WHERE external_id = $1
+	`)
+	updateVariantQuery := regexp.QuoteMeta(`
+		UPDATE product_variants
+		SET stock = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = $2
+		AND is_default
+	`)
+
+	// Create test data
+	rows := []models.ProductImportRow{
+		{Line: 2, ExternalID: "ext-1", Name: "Mouse", Price: 999, Currency: "USD", TaxClass: "standard", Stock: 5},
+		{Line: 3, ExternalID: "ext-2", Name: "Keyboard", Description: "Mechanical", Price: 4950, Currency: "EUR", TaxClass: "reduced", Stock: 2},
+	}
+	upsertColumns := []string{"product_id", "inserted"}
+
+	// Write testcases
+	tests := []struct {
+		name          string
+		mock          func()
+		expectErr     bool
+		expectResults []models.ImportRowResult
+	}{
+		{
+			name: "Creates new and updates existing products",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("ext-1", "Mouse", "", models.Money(999), "USD", "standard", 5).
+					WillReturnRows(sqlmock.NewRows(upsertColumns).AddRow("prod-1", true))
+				mock.ExpectExec(insertVariantQuery).
+					WithArgs("prod-1", 5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("ext-2", "Keyboard", "Mechanical", models.Money(4950), "EUR", "reduced", 2).
+					WillReturnRows(sqlmock.NewRows(upsertColumns).AddRow("prod-2", false))
+				mock.ExpectQuery(variantStockQuery).
+					WithArgs("prod-2").
+					WillReturnRows(sqlmock.NewRows([]string{"stock", "variants"}).AddRow(7, 0))
+				mock.ExpectExec(updateVariantQuery).
+					WithArgs(2, "prod-2").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+			expectResults: []models.ImportRowResult{
+				{Line: 2, ExternalID: "ext-1", ProductID: "prod-1", Action: models.ImportActionCreated},
+				{Line: 3, ExternalID: "ext-2", ProductID: "prod-2", Action: models.ImportActionUpdated},
+			},
+		},
+		{
+			name: "Archived product fails only its row",
+			mock: func() {
+				mock.ExpectBegin()
+
+				// The upsert leaves archived products alone and returns no row
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("ext-1", "Mouse", "", models.Money(999), "USD", "standard", 5).
+					WillReturnRows(sqlmock.NewRows(upsertColumns))
+				mock.ExpectQuery(archivedQuery).
+					WithArgs("ext-1").
+					WillReturnRows(sqlmock.NewRows([]string{"archived"}).AddRow(true))
+
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("ext-2", "Keyboard", "Mechanical", models.Money(4950), "EUR", "reduced", 2).
+					WillReturnRows(sqlmock.NewRows(upsertColumns).AddRow("prod-2", false))
+				mock.ExpectQuery(variantStockQuery).
+					WithArgs("prod-2").
+					WillReturnRows(sqlmock.NewRows([]string{"stock", "variants"}).AddRow(7, 0))
+				mock.ExpectExec(updateVariantQuery).
+					WithArgs(2, "prod-2").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+			expectResults: []models.ImportRowResult{
+				{Line: 2, ExternalID: "ext-1", Action: models.ImportActionFailed, Error: "product not found: product with external ID ext-1 is archived"},
+				{Line: 3, ExternalID: "ext-2", ProductID: "prod-2", Action: models.ImportActionUpdated},
+			},
+		},
+		{
+			name: "Product with variants fails only its row when the stock differs",
+			mock: func() {
+				mock.ExpectBegin()
+
+				// The upsert leaves products whose stock is set on their variants alone
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("ext-1", "Mouse", "", models.Money(999), "USD", "standard", 5).
+					WillReturnRows(sqlmock.NewRows(upsertColumns))
+				mock.ExpectQuery(archivedQuery).
+					WithArgs("ext-1").
+					WillReturnRows(sqlmock.NewRows([]string{"archived"}).AddRow(false))
+
+				// The same total stock is accepted and leaves the variants untouched
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("ext-2", "Keyboard", "Mechanical", models.Money(4950), "EUR", "reduced", 2).
+					WillReturnRows(sqlmock.NewRows(upsertColumns).AddRow("prod-2", false))
+				mock.ExpectQuery(variantStockQuery).
+					WithArgs("prod-2").
+					WillReturnRows(sqlmock.NewRows([]string{"stock", "variants"}).AddRow(2, 3))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+			expectResults: []models.ImportRowResult{
+				{Line: 2, ExternalID: "ext-1", Action: models.ImportActionFailed, Error: "stock of a product with variants is set on its variants: product with external ID ext-1 has variants, so only its current total stock can be imported"},
+				{Line: 3, ExternalID: "ext-2", ProductID: "prod-2", Action: models.ImportActionUpdated},
+			},
+		},
+		{
+			name: "Failing row rolls back the batch",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("ext-1", "Mouse", "", models.Money(999), "USD", "standard", 5).
+					WillReturnRows(sqlmock.NewRows(upsertColumns).AddRow("prod-1", true))
+				mock.ExpectExec(insertVariantQuery).
+					WithArgs("prod-1", 5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectQuery(upsertQuery).
+					WithArgs("ext-2", "Keyboard", "Mechanical", models.Money(4950), "EUR", "reduced", 2).
+					WillReturnError(errors.New("upsert error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			results, err := s.ImportBatchInDB(context.Background(), rows)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectResults, results)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -35,13 +35,13 @@ func TestGetAllFromDB(t *testing.T) {
 	defer db.Close()
 
 	// Create test data
-	now := time.Now()
+	now := time.Date(2025, 1, 12, 10, 30, 0, 0, time.UTC)
 	expectedProducts := []models.Product{
 		{
 			ProductID:   "prod-1",
 			Name:        "Test Product 1",
 			Description: "Description 1",
-			Price:       99.99,
+			Price:       9999,
 			Stock:       10,
 			CreatedAt:   now,
 			UpdatedAt:   now,
@@ -50,91 +50,314 @@ func TestGetAllFromDB(t *testing.T) {
 			ProductID:   "prod-2",
 			Name:        "Test Product 2",
 			Description: "Description 2",
-			Price:       149.99,
+			Price:       14999,
 			Stock:       5,
 			CreatedAt:   now,
 			UpdatedAt:   now,
 		},
 	}
 
+	productRows := func(products ...models.Product) *sqlmock.Rows {
+		rows := sqlmock.NewRows(
+			[]string{
+				"product_id",
+				"name",
+				"description",
+				"price",
+				"stock",
+				"created_at",
+				"updated_at",
+			},
+		)
+
+		for _, product := range products {
+			rows.AddRow(
+				product.ProductID,
+				product.Name,
+				product.Description,
+				product.Price,
+				product.Stock,
+				product.CreatedAt,
+				product.UpdatedAt,
+			)
+		}
+		return rows
+	}
+
+	countQuery := regexp.QuoteMeta(`
+		SELECT COUNT(*)
+		FROM products
+	`)
+	selectQuery := regexp.QuoteMeta(`
+		SELECT product_id, name, description, price, currency, tax_class, stock, created_at, updated_at
+		FROM products
+	`)
+
+	minPrice := models.Money(5000)
+
 	// Write testcases
 	tests := []struct {
-		name       string
-		mock       func()
-		expectErr  bool
-		expectRows []models.Product
+		name        string
+		query       models.ProductQuery
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+		expectPage  *models.ProductPage
 	}{
 		{
-			name: "Successful fetch",
+			name:  "Successful fetch",
+			query: models.ProductQuery{Limit: 20, Sort: models.ProductSortCreatedAt, Order: models.SortDesc},
 			mock: func() {
-				rows := sqlmock.NewRows(
-					[]string{
-						"product_id",
-						"name",
-						"description",
-						"price",
-						"stock",
-						"created_at",
-						"updated_at",
-					},
-				)
+				mock.ExpectQuery(countQuery + `\s*` + regexp.QuoteMeta(`WHERE deleted_at IS NULL`)).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+				mock.ExpectQuery(selectQuery + `\s*` + regexp.QuoteMeta(`WHERE deleted_at IS NULL ORDER BY created_at DESC, product_id DESC LIMIT 21`)).
+					WillReturnRows(productRows(expectedProducts...))
+			},
+			expectErr: false,
+			expectPage: &models.ProductPage{
+				Items:      expectedProducts,
+				Pagination: models.Pagination{Total: 2, Limit: 20},
+			},
+		},
+		{
+			name:  "No rows found",
+			query: models.ProductQuery{Limit: 20, Sort: models.ProductSortCreatedAt, Order: models.SortDesc},
+			mock: func() {
+				mock.ExpectQuery(countQuery).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+				mock.ExpectQuery(selectQuery).
+					WillReturnRows(productRows())
+			},
+			expectErr: false,
+			expectPage: &models.ProductPage{
+				Items:      []models.Product{},
+				Pagination: models.Pagination{Total: 0, Limit: 20},
+			},
+		},
+		{
+			name:  "Filtered fetch",
+			query: models.ProductQuery{Limit: 2, Sort: models.ProductSortPrice, Order: models.SortAsc, MinPrice: &minPrice, InStock: true},
+			mock: func() {
+				mock.ExpectQuery(countQuery + `\s*` + regexp.QuoteMeta(`WHERE deleted_at IS NULL AND price >= $1 AND COALESCE(stock, 0) > 0`)).
+					WithArgs(minPrice).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+				mock.ExpectQuery(regexp.QuoteMeta(`WHERE deleted_at IS NULL AND price >= $1 AND COALESCE(stock, 0) > 0 ORDER BY price ASC, product_id ASC LIMIT 3`)).
+					WithArgs(minPrice).
+					WillReturnRows(productRows(expectedProducts...))
+			},
+			expectErr: false,
+			expectPage: &models.ProductPage{
+				Items:      expectedProducts,
+				Pagination: models.Pagination{Total: 2, Limit: 2},
+			},
+		},
+		{
+			name:        "Unknown sort field",
+			query:       models.ProductQuery{Limit: 20, Sort: "price; DROP TABLE products", Order: models.SortAsc},
+			mock:        func() {},
+			expectErr:   true,
+			expectErrIs: store.ErrInvalidProductQuery,
+		},
+		{
+			name:        "Invalid cursor",
+			query:       models.ProductQuery{Limit: 20, Sort: models.ProductSortPrice, Order: models.SortAsc, Cursor: "not-a-cursor"},
+			mock:        func() {},
+			expectErr:   true,
+			expectErrIs: store.ErrInvalidCursor,
+		},
+		{
+			name:  "Query error",
+			query: models.ProductQuery{Limit: 20, Sort: models.ProductSortCreatedAt, Order: models.SortDesc},
+			mock: func() {
+				mock.ExpectQuery(countQuery).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+				mock.ExpectQuery(selectQuery).
+					WillReturnError(errors.New("query error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			page, err := s.GetAllFromDB(context.Background(), tt.query)
 
-				for _, product := range expectedProducts {
-					rows.AddRow(
-						product.ProductID,
-						product.Name,
-						product.Description,
-						product.Price,
-						product.Stock,
-						product.CreatedAt,
-						product.UpdatedAt,
-					)
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
 				}
+				assert.Nil(t, page)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectPage, page)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
 
-				mock.ExpectQuery(regexp.QuoteMeta(`
-						SELECT product_id, name, description, price, stock, created_at, updated_at
-						FROM products
-					`)).
-					WillReturnRows(rows)
+func TestGetAllFromDBCursor(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewProductStore(db)
+	defer db.Close()
+
+	now := time.Date(2025, 1, 12, 10, 30, 0, 0, time.UTC)
+	columns := []string{"product_id", "name", "description", "price", "stock", "created_at", "updated_at"}
+	query := models.ProductQuery{Limit: 1, Sort: models.ProductSortPrice, Order: models.SortAsc}
+
+	// First page returns one extra row, so a cursor is issued
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*)`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`ORDER BY price ASC, product_id ASC LIMIT 2`)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("prod-1", "Test Product 1", "Description 1", 99.99, 10, now, now).
+			AddRow("prod-2", "Test Product 2", "Description 2", 149.99, 5, now, now))
+
+	page, err := s.GetAllFromDB(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.NotEmpty(t, page.Pagination.NextCursor)
+
+	// Second page continues after the last row of the first
+	query.Cursor = page.Pagination.NextCursor
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*)`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE deleted_at IS NULL AND (price, product_id) > ($1::numeric, $2::uuid) ORDER BY price ASC, product_id ASC LIMIT 2`)).
+		WithArgs("99.99", "prod-1").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("prod-2", "Test Product 2", "Description 2", 149.99, 5, now, now))
+
+	page, err = s.GetAllFromDB(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.Pagination.NextCursor)
+
+	// A cursor cannot be replayed against another sort
+	query.Sort = models.ProductSortName
+
+	page, err = s.GetAllFromDB(context.Background(), query)
+	assert.ErrorIs(t, err, store.ErrInvalidCursor)
+	assert.Nil(t, page)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchFromDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewProductStore(db)
+	defer db.Close()
+
+	now := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	columns := []string{"product_id", "name", "description", "price", "stock", "created_at", "updated_at", "rank", "highlight"}
+
+	countQuery := regexp.QuoteMeta(`
+		SELECT COUNT(*)
+		FROM products, websearch_to_tsquery('english', $1) query
+		WHERE search_vector @@ query
+	`)
+	searchQuery := regexp.QuoteMeta(`ts_rank(search_vector, query) AS rank`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		query       models.ProductQuery
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+		expectTotal int
+		expectItems int
+		expectNext  bool
+		// Highlights of the returned items, when checked
+		expectHighlights []string
+	}{
+		{
+			name:  "Ranked page with next cursor",
+			query: models.ProductQuery{Search: "running shoes", Limit: 1},
+			mock: func() {
+				mock.ExpectQuery(countQuery).
+					WithArgs("running shoes").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+				mock.ExpectQuery(searchQuery + `.*` + regexp.QuoteMeta(`LIMIT 2 OFFSET 0`)).
+					WithArgs("running shoes").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow("prod-1", "Running Shoes", "Light", 99.99, 10, now, now, 0.6, "\x02Running\x03 \x02Shoes\x03 & more").
+						AddRow("prod-2", "Trail Shoes", "Grippy", 149.99, 5, now, now, 0.3, "Trail \x02Shoes\x03"))
 			},
-			expectErr:  false,
-			expectRows: expectedProducts,
+			expectErr:        false,
+			expectTotal:      2,
+			expectItems:      1,
+			expectNext:       true,
+			expectHighlights: []string{"<mark>Running</mark> <mark>Shoes</mark> &amp; more"},
 		},
 		{
-			name: "No rows found",
+			name:  "Markup in product text is escaped",
+			query: models.ProductQuery{Search: "shoes", Limit: 20},
 			mock: func() {
-				rows := sqlmock.NewRows(
-					[]string{
-						"product_id",
-						"name",
-						"description",
-						"price",
-						"stock",
-						"created_at",
-						"updated_at",
-					},
-				)
+				mock.ExpectQuery(countQuery).
+					WithArgs("shoes").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+				mock.ExpectQuery(searchQuery).
+					WithArgs("shoes").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow("prod-1", "Shoes", `<img src=x onerror="alert(1)">`, 99.99, 10, now, now, 0.6,
+							"\x02Shoes\x03 <img src=x onerror=\"alert(1)\"></mark><script>"))
+			},
+			expectErr:        false,
+			expectTotal:      1,
+			expectItems:      1,
+			expectHighlights: []string{`<mark>Shoes</mark> &lt;img src=x onerror=&#34;alert(1)&#34;&gt;&lt;/mark&gt;&lt;script&gt;`},
+		},
+		{
+			name:  "Filtered search",
+			query: models.ProductQuery{Search: "shoes", Limit: 20, InStock: true},
+			mock: func() {
+				mock.ExpectQuery(countQuery + `\s*` + regexp.QuoteMeta(`AND deleted_at IS NULL AND COALESCE(stock, 0) > 0`)).
+					WithArgs("shoes").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 
-				mock.ExpectQuery(regexp.QuoteMeta(`
-					SELECT product_id, name, description, price, stock, created_at, updated_at
-					FROM products
-				`)).
-					WillReturnRows(rows)
+				mock.ExpectQuery(searchQuery).
+					WithArgs("shoes").
+					WillReturnRows(sqlmock.NewRows(columns))
 			},
-			expectErr:  false,
-			expectRows: []models.Product(nil),
+			expectErr:   false,
+			expectTotal: 0,
+			expectItems: 0,
 		},
 		{
-			name: "Query error",
+			name:        "Cursor from another search",
+			query:       models.ProductQuery{Search: "shoes", Limit: 20, Cursor: "eyJxIjoiaGF0cyIsIm9mZiI6MjB9"},
+			mock:        func() {},
+			expectErr:   true,
+			expectErrIs: store.ErrInvalidCursor,
+		},
+		{
+			name:  "Query error",
+			query: models.ProductQuery{Search: "shoes", Limit: 20},
 			mock: func() {
-				mock.ExpectQuery(regexp.QuoteMeta(`
-					SELECT product_id, name, description, price, stock, created_at, updated_at
-					FROM products
-				`)).
+				mock.ExpectQuery(countQuery).
+					WithArgs("shoes").
 					WillReturnError(errors.New("query error"))
 			},
-			expectErr:  true,
-			expectRows: nil,
+			expectErr: true,
 		},
 	}
 
@@ -142,14 +365,26 @@ func TestGetAllFromDB(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			products, err := s.GetAllFromDB(context.Background())
+			page, err := s.SearchFromDB(context.Background(), tt.query)
 
 			if tt.expectErr {
 				assert.Error(t, err)
-				assert.Nil(t, products)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+				assert.Nil(t, page)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expectRows, products)
+				assert.Equal(t, tt.expectTotal, page.Pagination.Total)
+				assert.Len(t, page.Items, tt.expectItems)
+				assert.Equal(t, tt.expectNext, page.Pagination.NextCursor != "")
+				if tt.expectHighlights != nil {
+					var highlights []string
+					for _, item := range page.Items {
+						highlights = append(highlights, item.Highlight)
+					}
+					assert.Equal(t, tt.expectHighlights, highlights)
+				}
 			}
 			assert.NoError(t, mock.ExpectationsWereMet())
 		})
@@ -172,10 +407,11 @@ func TestGetByIDFromDB(t *testing.T) {
 		ProductID:   "prod-1",
 		Name:        "Test Product 1",
 		Description: "Description 1",
-		Price:       99.99,
+		Price:       9999,
 		Stock:       10,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		Version:     2,
 	}
 
 	// Write testcases
@@ -198,24 +434,31 @@ func TestGetByIDFromDB(t *testing.T) {
 						"name",
 						"description",
 						"price",
+						"currency",
+						"tax_class",
 						"stock",
 						"created_at",
 						"updated_at",
+						"version",
 					},
 				).AddRow(
 					product.ProductID,
 					product.Name,
 					product.Description,
 					product.Price,
+					product.Currency,
+					product.TaxClass,
 					product.Stock,
 					product.CreatedAt,
 					product.UpdatedAt,
+					product.Version,
 				)
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					SELECT product_id, name, description, price, stock, created_at, updated_at
+					SELECT product_id, name, description, price, currency, tax_class, stock, created_at, updated_at, version
 					FROM products
 					WHERE product_id = $1
+					AND deleted_at IS NULL
 				`)).WithArgs(product.ProductID).WillReturnRows(rows)
 			},
 			expectErr: false,
@@ -226,9 +469,10 @@ func TestGetByIDFromDB(t *testing.T) {
 			productID: "nonexistent-id",
 			mock: func() {
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					SELECT product_id, name, description, price, stock, created_at, updated_at
+					SELECT product_id, name, description, price, currency, tax_class, stock, created_at, updated_at, version
 					FROM products
 					WHERE product_id = $1
+					AND deleted_at IS NULL
 				`)).WithArgs("nonexistent-id").WillReturnError(sql.ErrNoRows)
 			},
 			expectErr: true,
@@ -239,9 +483,10 @@ func TestGetByIDFromDB(t *testing.T) {
 			productID: "prod-2",
 			mock: func() {
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					SELECT product_id, name, description, price, stock, created_at, updated_at
+					SELECT product_id, name, description, price, currency, tax_class, stock, created_at, updated_at, version
 					FROM products
 					WHERE product_id = $1
+					AND deleted_at IS NULL
 				`)).WithArgs("prod-2").WillReturnError(errors.New("query error"))
 			},
 			expectErr: true,
@@ -281,7 +526,7 @@ func TestCreateInDB(t *testing.T) {
 	product := models.Product{
 		Name:        "Test Product",
 		Description: "A test product",
-		Price:       100.0,
+		Price:       10000,
 		Stock:       50,
 	}
 
@@ -307,16 +552,26 @@ func TestCreateInDB(t *testing.T) {
 				).AddRow("new-product-id")
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					INSERT INTO products (product_id, name, description, price, stock, created_at, updated_at)
-					VALUES (gen_random_uuid(), $1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+					INSERT INTO products (product_id, name, description, price, currency, tax_class, stock, created_at, updated_at)
+					VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 					RETURNING product_id
 				`)).WithArgs(
 					product.Name,
 					product.Description,
 					product.Price,
+					product.Currency,
+					product.TaxClass,
 					product.Stock,
 				).WillReturnRows(rows)
 
+				// The default variant takes the product stock
+				mock.ExpectExec(regexp.QuoteMeta(`
+					INSERT INTO product_variants (variant_id, product_id, sku, options, price, stock, is_default, created_at, updated_at)
+				`)).WithArgs(
+					"new-product-id",
+					product.Stock,
+				).WillReturnResult(sqlmock.NewResult(0, 1))
+
 				mock.ExpectCommit()
 			},
 			expectErr: false,
@@ -340,13 +595,15 @@ func TestCreateInDB(t *testing.T) {
 				mock.ExpectBegin()
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					INSERT INTO products (product_id, name, description, price, stock, created_at, updated_at)
-					VALUES (gen_random_uuid(), $1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+					INSERT INTO products (product_id, name, description, price, currency, tax_class, stock, created_at, updated_at)
+					VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 					RETURNING product_id
 				`)).WithArgs(
 					product.Name,
 					product.Description,
 					product.Price,
+					product.Currency,
+					product.TaxClass,
 					product.Stock,
 				).WillReturnError(errors.New("query error"))
 
@@ -369,16 +626,26 @@ func TestCreateInDB(t *testing.T) {
 				).AddRow("new-product-id")
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					INSERT INTO products (product_id, name, description, price, stock, created_at, updated_at)
-					VALUES (gen_random_uuid(), $1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+					INSERT INTO products (product_id, name, description, price, currency, tax_class, stock, created_at, updated_at)
+					VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 					RETURNING product_id
 				`)).WithArgs(
 					product.Name,
 					product.Description,
 					product.Price,
+					product.Currency,
+					product.TaxClass,
 					product.Stock,
 				).WillReturnRows(rows)
 
+				// The default variant takes the product stock
+				mock.ExpectExec(regexp.QuoteMeta(`
+					INSERT INTO product_variants (variant_id, product_id, sku, options, price, stock, is_default, created_at, updated_at)
+				`)).WithArgs(
+					"new-product-id",
+					product.Stock,
+				).WillReturnResult(sqlmock.NewResult(0, 1))
+
 				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
 			},
 			expectErr: true,
@@ -419,18 +686,31 @@ func TestPutUpdateInDB(t *testing.T) {
 	product := models.Product{
 		Name:        "Updated Product",
 		Description: "Updated description",
-		Price:       150.0,
+		Price:       15000,
 		Stock:       30,
 	}
 	productID := "existing-product-id"
+	version := 3
+	versionQuery := regexp.QuoteMeta(`
+		SELECT version
+		FROM products
+		WHERE product_id = $1
+	`)
+	variantStockQuery := regexp.QuoteMeta(`
+		SELECT COALESCE(SUM(stock), 0) AS stock, COUNT(*) FILTER (WHERE NOT is_default) AS variants
+		FROM product_variants
+		WHERE product_id = $1
+	`)
 
 	// Write testcases
 	tests := []struct {
-		name      string
-		product   *models.Product
-		productID string
-		mock      func()
-		expectErr bool
+		name          string
+		product       *models.Product
+		productID     string
+		mock          func()
+		expectErr     bool
+		expectErrIs   error
+		expectVersion int
 	}{
 		{
 			name:      "Successful put product update",
@@ -442,26 +722,126 @@ func TestPutUpdateInDB(t *testing.T) {
 
 				rows := sqlmock.NewRows(
 					[]string{
-						"product_id",
+						"version",
 					},
-				).AddRow(productID)
+				).AddRow(version + 1)
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
 					UPDATE PRODUCTS
-					SET name=$1, description=$2, price=$3, stock=$4, updated_at = CURRENT_TIMESTAMP
-					WHERE product_id = $5
-					RETURNING product_id
+					SET name=$1, description=$2, price=$3, currency=$4, tax_class=$5, stock=$6, updated_at = CURRENT_TIMESTAMP
+					WHERE product_id = $7
+					AND deleted_at IS NULL
+					AND version = $8
+					RETURNING version
 				`)).WithArgs(
 					product.Name,
 					product.Description,
 					product.Price,
+					product.Currency,
+					product.TaxClass,
 					product.Stock,
 					productID,
+					version,
 				).WillReturnRows(rows)
 
+				// The product has no variants besides the default one
+				mock.ExpectQuery(variantStockQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"stock", "variants"}).AddRow(12, 0),
+				)
+
+				// Stock is applied to the default variant
+				mock.ExpectExec(regexp.QuoteMeta(`
+					UPDATE product_variants
+					SET stock = $1, updated_at = CURRENT_TIMESTAMP
+				`)).WithArgs(
+					product.Stock,
+					productID,
+				).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				// The stock trigger bumped the version again
+				mock.ExpectQuery(versionQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"version"}).AddRow(version + 2),
+				)
+
 				mock.ExpectCommit()
 			},
-			expectErr: false,
+			expectErr:     false,
+			expectVersion: version + 2,
+		},
+		{
+			name:      "Product with other variants keeps its total stock",
+			product:   &product,
+			productID: productID,
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(regexp.QuoteMeta(`
+					UPDATE PRODUCTS
+					SET name=$1, description=$2, price=$3, currency=$4, tax_class=$5, stock=$6, updated_at = CURRENT_TIMESTAMP
+					WHERE product_id = $7
+					AND deleted_at IS NULL
+					AND version = $8
+					RETURNING version
+				`)).WithArgs(
+					product.Name,
+					product.Description,
+					product.Price,
+					product.Currency,
+					product.TaxClass,
+					product.Stock,
+					productID,
+					version,
+				).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version + 1))
+
+				// The stock sent matches the variants, so none of them change
+				mock.ExpectQuery(variantStockQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"stock", "variants"}).AddRow(product.Stock, 2),
+				)
+
+				// The stock trigger bumped the version again
+				mock.ExpectQuery(versionQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"version"}).AddRow(version + 2),
+				)
+
+				mock.ExpectCommit()
+			},
+			expectErr:     false,
+			expectVersion: version + 2,
+		},
+		{
+			name:      "Product with other variants rejects a different stock",
+			product:   &product,
+			productID: productID,
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(regexp.QuoteMeta(`
+					UPDATE PRODUCTS
+					SET name=$1, description=$2, price=$3, currency=$4, tax_class=$5, stock=$6, updated_at = CURRENT_TIMESTAMP
+					WHERE product_id = $7
+					AND deleted_at IS NULL
+					AND version = $8
+					RETURNING version
+				`)).WithArgs(
+					product.Name,
+					product.Description,
+					product.Price,
+					product.Currency,
+					product.TaxClass,
+					product.Stock,
+					productID,
+					version,
+				).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version + 1))
+
+				// The variants hold a different total stock
+				mock.ExpectQuery(variantStockQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"stock", "variants"}).AddRow(12, 2),
+				)
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrStockSetOnVariants,
 		},
 		{
 			name:      "Error starting transaction",
@@ -474,7 +854,7 @@ func TestPutUpdateInDB(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name:      "Product not found (No rows affected)",
+			name:      "Product changed since it was read (No rows affected)",
 			product:   &product,
 			productID: "nonexistent-id",
 			mock: func() {
@@ -483,15 +863,20 @@ func TestPutUpdateInDB(t *testing.T) {
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
 					UPDATE PRODUCTS
-					SET name=$1, description=$2, price=$3, stock=$4, updated_at = CURRENT_TIMESTAMP
-					WHERE product_id = $5
-					RETURNING product_id
+					SET name=$1, description=$2, price=$3, currency=$4, tax_class=$5, stock=$6, updated_at = CURRENT_TIMESTAMP
+					WHERE product_id = $7
+					AND deleted_at IS NULL
+					AND version = $8
+					RETURNING version
 				`)).WithArgs(
 					product.Name,
 					product.Description,
 					product.Price,
+					product.Currency,
+					product.TaxClass,
 					product.Stock,
 					"nonexistent-id",
+					version,
 				).WillReturnError(sql.ErrNoRows)
 
 				mock.ExpectRollback()
@@ -508,15 +893,20 @@ func TestPutUpdateInDB(t *testing.T) {
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
 					UPDATE PRODUCTS
-					SET name=$1, description=$2, price=$3, stock=$4, updated_at = CURRENT_TIMESTAMP
-					WHERE product_id = $5
-					RETURNING product_id
+					SET name=$1, description=$2, price=$3, currency=$4, tax_class=$5, stock=$6, updated_at = CURRENT_TIMESTAMP
+					WHERE product_id = $7
+					AND deleted_at IS NULL
+					AND version = $8
+					RETURNING version
 				`)).WithArgs(
 					product.Name,
 					product.Description,
 					product.Price,
+					product.Currency,
+					product.TaxClass,
 					product.Stock,
 					productID,
+					version,
 				).WillReturnError(errors.New("query error"))
 
 				mock.ExpectRollback()
@@ -533,23 +923,47 @@ func TestPutUpdateInDB(t *testing.T) {
 
 				rows := sqlmock.NewRows(
 					[]string{
-						"product_id",
+						"version",
 					},
-				).AddRow(productID)
+				).AddRow(version + 1)
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
 					UPDATE PRODUCTS
-					SET name=$1, description=$2, price=$3, stock=$4, updated_at = CURRENT_TIMESTAMP
-					WHERE product_id = $5
-					RETURNING product_id
+					SET name=$1, description=$2, price=$3, currency=$4, tax_class=$5, stock=$6, updated_at = CURRENT_TIMESTAMP
+					WHERE product_id = $7
+					AND deleted_at IS NULL
+					AND version = $8
+					RETURNING version
 				`)).WithArgs(
 					product.Name,
 					product.Description,
 					product.Price,
+					product.Currency,
+					product.TaxClass,
 					product.Stock,
 					productID,
+					version,
 				).WillReturnRows(rows)
 
+				// The product has no variants besides the default one
+				mock.ExpectQuery(variantStockQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"stock", "variants"}).AddRow(12, 0),
+				)
+
+				// Stock is applied to the default variant
+				mock.ExpectExec(regexp.QuoteMeta(`
+					UPDATE product_variants
+					SET stock = $1, updated_at = CURRENT_TIMESTAMP
+				`)).WithArgs(
+					product.Stock,
+					productID,
+				).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				// The stock trigger bumped the version again
+				mock.ExpectQuery(versionQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"version"}).AddRow(version + 2),
+				)
+
 				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
 			},
 			expectErr: true,
@@ -560,12 +974,16 @@ func TestPutUpdateInDB(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			err := s.PutUpdateInDB(context.Background(), tt.product, tt.productID)
+			err := s.PutUpdateInDB(context.Background(), tt.product, tt.productID, version)
 
 			if tt.expectErr {
 				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.expectVersion, tt.product.Version)
 			}
 
 			assert.NoError(t, mock.ExpectationsWereMet())
@@ -585,104 +1003,157 @@ func TestPatchUpdateInDB(t *testing.T) {
 
 	// Create test data
 	productID := "existing-product-id"
-
-	product_all_fields := models.Product{
-		Name:        "Updated Product",
-		Description: "Updated description",
-		Price:       150.0,
-		Stock:       30,
+	version := 3
+	versionQuery := regexp.QuoteMeta(`
+		SELECT version
+		FROM products
+		WHERE product_id = $1
+	`)
+	variantStockQuery := regexp.QuoteMeta(`
+		SELECT COALESCE(SUM(stock), 0) AS stock, COUNT(*) FILTER (WHERE NOT is_default) AS variants
+		FROM product_variants
+		WHERE product_id = $1
+	`)
+
+	soldOut := 0
+	name := "Updated Product"
+	emptyDescription := ""
+
+	patch_stock := models.ProductPatch{
+		Stock: &soldOut,
 	}
 
-	product_missing_fields := models.Product{
-		Price: 100.0,
-		Stock: 50,
+	patch_name_description := models.ProductPatch{
+		Name:        &name,
+		Description: &emptyDescription,
 	}
 
+	stockQuery := regexp.QuoteMeta(`
+		UPDATE PRODUCTS
+		SET stock = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = $2
+		AND deleted_at IS NULL
+		AND version = $3
+		RETURNING version
+	`)
+
 	// Write testcases
 	tests := []struct {
-		name      string
-		product   *models.Product
-		productID string
-		mock      func()
-		expectErr bool
+		name          string
+		patch         *models.ProductPatch
+		mock          func()
+		expectErr     bool
+		expectErrIs   error
+		expectVersion int
 	}{
 		{
-			name:      "Successful patch update with all fields",
-			product:   &product_all_fields,
-			productID: productID,
+			name:  "Successful patch setting stock to zero",
+			patch: &patch_stock,
 			mock: func() {
 				// Mock transaction and query for updating product
 				mock.ExpectBegin()
 
 				rows := sqlmock.NewRows(
 					[]string{
-						"product_id",
+						"version",
 					},
-				).AddRow(productID)
+				).AddRow(version + 1)
 
-				mock.ExpectQuery(regexp.QuoteMeta(`
-					UPDATE PRODUCTS
-					SET
-						name = COALESCE(NULLIF($1, ''), name),
-						description = COALESCE(NULLIF($2, ''), description),
-						price = COALESCE(NULLIF($3, 0), price),
-						stock = COALESCE(NULLIF($4, 0), stock),
-						updated_at = CURRENT_TIMESTAMP
-					WHERE product_id = $5
-					RETURNING product_id
-				`)).WithArgs(
-					product_all_fields.Name,
-					product_all_fields.Description,
-					product_all_fields.Price,
-					product_all_fields.Stock,
+				mock.ExpectQuery(stockQuery).WithArgs(
+					soldOut,
 					productID,
+					version,
 				).WillReturnRows(rows)
 
+				// The product has no variants besides the default one
+				mock.ExpectQuery(variantStockQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"stock", "variants"}).AddRow(12, 0),
+				)
+
+				// Stock is applied to the default variant
+				mock.ExpectExec(regexp.QuoteMeta(`
+					UPDATE product_variants
+					SET stock = $1, updated_at = CURRENT_TIMESTAMP
+				`)).WithArgs(
+					soldOut,
+					productID,
+				).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				// The stock trigger bumped the version again
+				mock.ExpectQuery(versionQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"version"}).AddRow(version + 2),
+				)
+
 				mock.ExpectCommit()
 			},
-			expectErr: false,
+			expectErr:     false,
+			expectVersion: version + 2,
 		},
 		{
-			name:      "Successful patch update with empty Name and Description fields",
-			product:   &product_missing_fields,
-			productID: productID,
+			name:  "Product with other variants cannot be marked sold out",
+			patch: &patch_stock,
 			mock: func() {
-				// Mock transaction and query for updating product
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(stockQuery).WithArgs(
+					soldOut,
+					productID,
+					version,
+				).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version + 1))
+
+				// Zeroing the default variant would leave the others in stock
+				mock.ExpectQuery(variantStockQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"stock", "variants"}).AddRow(12, 2),
+				)
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrStockSetOnVariants,
+		},
+		{
+			name:  "Successful patch clearing the description",
+			patch: &patch_name_description,
+			mock: func() {
+				// Only name and description are written, and the variants are left alone
 				mock.ExpectBegin()
 
 				rows := sqlmock.NewRows(
 					[]string{
-						"product_id",
+						"version",
 					},
-				).AddRow(productID)
+				).AddRow(version + 1)
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
 					UPDATE PRODUCTS
-					SET
-						name = COALESCE(NULLIF($1, ''), name),
-						description = COALESCE(NULLIF($2, ''), description),
-						price = COALESCE(NULLIF($3, 0), price),
-						stock = COALESCE(NULLIF($4, 0), stock),
-						updated_at = CURRENT_TIMESTAMP
-					WHERE product_id = $5
-					RETURNING product_id
+					SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP
+					WHERE product_id = $3
+					AND deleted_at IS NULL
+					AND version = $4
+					RETURNING version
 				`)).WithArgs(
-					"",
-					"",
-					product_missing_fields.Price,
-					product_missing_fields.Stock,
+					name,
+					emptyDescription,
 					productID,
+					version,
 				).WillReturnRows(rows)
 
 				mock.ExpectCommit()
 			},
-
-			expectErr: false,
+			expectErr:     false,
+			expectVersion: version + 1,
 		},
 		{
-			name:      "Error starting transaction",
-			product:   &product_all_fields,
-			productID: productID,
+			name:  "Empty patch",
+			patch: &models.ProductPatch{},
+			mock: func() {
+				// Nothing reaches the DB
+			},
+			expectErr: true,
+		},
+		{
+			name:  "Error starting transaction",
+			patch: &patch_stock,
 			mock: func() {
 				// Simulate an error when starting the transaction
 				mock.ExpectBegin().WillReturnError(errors.New("failed to start transaction"))
@@ -690,59 +1161,33 @@ func TestPatchUpdateInDB(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name:      "Product not found (No rows affected)",
-			product:   &product_all_fields,
-			productID: "nonexistent-id",
+			name:  "Product changed since it was read (No rows affected)",
+			patch: &patch_stock,
 			mock: func() {
 				// Simulate an error when no rows are affected by the update
 				mock.ExpectBegin()
 
-				mock.ExpectQuery(regexp.QuoteMeta(`
-					UPDATE PRODUCTS
-					SET
-						name = COALESCE(NULLIF($1, ''), name),
-						description = COALESCE(NULLIF($2, ''), description),
-						price = COALESCE(NULLIF($3, 0), price),
-						stock = COALESCE(NULLIF($4, 0), stock),
-						updated_at = CURRENT_TIMESTAMP
-					WHERE product_id = $5
-					RETURNING product_id
-				`)).WithArgs(
-					product_all_fields.Name,
-					product_all_fields.Description,
-					product_all_fields.Price,
-					product_all_fields.Stock,
-					"nonexistent-id",
-				)
+				mock.ExpectQuery(stockQuery).WithArgs(
+					soldOut,
+					productID,
+					version,
+				).WillReturnError(sql.ErrNoRows)
 
 				mock.ExpectRollback()
 			},
 			expectErr: true,
 		},
 		{
-			name:      "Query execution error",
-			product:   &product_all_fields,
-			productID: productID,
+			name:  "Query execution error",
+			patch: &patch_stock,
 			mock: func() {
 				// Simulate a query error
 				mock.ExpectBegin()
 
-				mock.ExpectQuery(regexp.QuoteMeta(`
-					UPDATE PRODUCTS
-					SET
-						name = COALESCE(NULLIF($1, ''), name),
-						description = COALESCE(NULLIF($2, ''), description),
-						price = COALESCE(NULLIF($3, 0), price),
-						stock = COALESCE(NULLIF($4, 0), stock),
-						updated_at = CURRENT_TIMESTAMP
-					WHERE product_id = $5
-					RETURNING product_id
-				`)).WithArgs(
-					product_all_fields.Name,
-					product_all_fields.Description,
-					product_all_fields.Price,
-					product_all_fields.Stock,
+				mock.ExpectQuery(stockQuery).WithArgs(
+					soldOut,
 					productID,
+					version,
 				).WillReturnError(errors.New("query error"))
 
 				mock.ExpectRollback()
@@ -750,37 +1195,43 @@ func TestPatchUpdateInDB(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name:      "Error committing transaction",
-			product:   &product_all_fields,
-			productID: productID,
+			name:  "Error committing transaction",
+			patch: &patch_stock,
 			mock: func() {
 				// Simulate a successful query but an error on commit
 				mock.ExpectBegin()
 
 				rows := sqlmock.NewRows(
 					[]string{
-						"product_id",
+						"version",
 					},
-				).AddRow(productID)
+				).AddRow(version + 1)
 
-				mock.ExpectQuery(regexp.QuoteMeta(`
-					UPDATE PRODUCTS
-					SET
-						name = COALESCE(NULLIF($1, ''), name),
-						description = COALESCE(NULLIF($2, ''), description),
-						price = COALESCE(NULLIF($3, 0), price),
-						stock = COALESCE(NULLIF($4, 0), stock),
-						updated_at = CURRENT_TIMESTAMP
-					WHERE product_id = $5
-					RETURNING product_id
-				`)).WithArgs(
-					product_all_fields.Name,
-					product_all_fields.Description,
-					product_all_fields.Price,
-					product_all_fields.Stock,
+				mock.ExpectQuery(stockQuery).WithArgs(
+					soldOut,
 					productID,
+					version,
 				).WillReturnRows(rows)
 
+				// The product has no variants besides the default one
+				mock.ExpectQuery(variantStockQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"stock", "variants"}).AddRow(12, 0),
+				)
+
+				// Stock is applied to the default variant
+				mock.ExpectExec(regexp.QuoteMeta(`
+					UPDATE product_variants
+					SET stock = $1, updated_at = CURRENT_TIMESTAMP
+				`)).WithArgs(
+					soldOut,
+					productID,
+				).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				// The stock trigger bumped the version again
+				mock.ExpectQuery(versionQuery).WithArgs(productID).WillReturnRows(
+					sqlmock.NewRows([]string{"version"}).AddRow(version + 2),
+				)
+
 				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
 			},
 			expectErr: true,
@@ -791,12 +1242,16 @@ func TestPatchUpdateInDB(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			err := s.PatchUpdateInDB(context.Background(), tt.product, tt.productID)
+			newVersion, err := s.PatchUpdateInDB(context.Background(), tt.patch, productID, version)
 
 			if tt.expectErr {
 				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.expectVersion, newVersion)
 			}
 
 			assert.NoError(t, mock.ExpectationsWereMet())
@@ -816,6 +1271,7 @@ func TestDeleteFromDB(t *testing.T) {
 
 	// Create test data
 	productID := "existing-product-id"
+	version := 3
 
 	// Write testcases
 	tests := []struct {
@@ -838,10 +1294,19 @@ func TestDeleteFromDB(t *testing.T) {
 				).AddRow(productID)
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					DELETE FROM products
+					UPDATE products
+					SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 					WHERE product_id = $1
+					AND deleted_at IS NULL
+					AND version = $2
 					RETURNING product_id
-				`)).WithArgs(productID).WillReturnRows(rows)
+				`)).WithArgs(productID, version).WillReturnRows(rows)
+
+				// Archived products leave every cart
+				mock.ExpectExec(regexp.QuoteMeta(`
+					DELETE FROM cart_items
+					WHERE product_id = $1
+				`)).WithArgs(productID).WillReturnResult(sqlmock.NewResult(0, 1))
 
 				mock.ExpectCommit()
 			},
@@ -857,17 +1322,20 @@ func TestDeleteFromDB(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name:      "Product not found (No rows affected)",
+			name:      "Product changed since it was read (No rows affected)",
 			productID: "nonexistent-id",
 			mock: func() {
 				// Simulate an error when no rows are affected by the delete
 				mock.ExpectBegin()
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					DELETE FROM products
+					UPDATE products
+					SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 					WHERE product_id = $1
+					AND deleted_at IS NULL
+					AND version = $2
 					RETURNING product_id
-				`)).WithArgs("nonexistent-id").WillReturnError(sql.ErrNoRows)
+				`)).WithArgs("nonexistent-id", version).WillReturnError(sql.ErrNoRows)
 
 				mock.ExpectRollback()
 			},
@@ -881,10 +1349,13 @@ func TestDeleteFromDB(t *testing.T) {
 				mock.ExpectBegin()
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					DELETE FROM products
+					UPDATE products
+					SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 					WHERE product_id = $1
+					AND deleted_at IS NULL
+					AND version = $2
 					RETURNING product_id
-				`)).WithArgs(productID).WillReturnError(errors.New("query error"))
+				`)).WithArgs(productID, version).WillReturnError(errors.New("query error"))
 
 				mock.ExpectRollback()
 			},
@@ -904,10 +1375,19 @@ func TestDeleteFromDB(t *testing.T) {
 				).AddRow(productID)
 
 				mock.ExpectQuery(regexp.QuoteMeta(`
-					DELETE FROM products
+					UPDATE products
+					SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 					WHERE product_id = $1
+					AND deleted_at IS NULL
+					AND version = $2
 					RETURNING product_id
-				`)).WithArgs(productID).WillReturnRows(rows)
+				`)).WithArgs(productID, version).WillReturnRows(rows)
+
+				// Archived products leave every cart
+				mock.ExpectExec(regexp.QuoteMeta(`
+					DELETE FROM cart_items
+					WHERE product_id = $1
+				`)).WithArgs(productID).WillReturnResult(sqlmock.NewResult(0, 1))
 
 				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
 			},
@@ -919,7 +1399,7 @@ func TestDeleteFromDB(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			err := s.DeleteFromDB(context.Background(), tt.productID)
+			err := s.DeleteFromDB(context.Background(), tt.productID, version)
 
 			if tt.expectErr {
 				assert.Error(t, err)
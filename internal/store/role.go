@@ -0,0 +1,260 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+var (
+	ErrRoleNotFound    = errors.New("role not found")
+	ErrRoleNotAssigned = errors.New("role not assigned to user")
+)
+
+type RoleStore interface {
+	GetAllFromDB(ctx context.Context) ([]models.Role, error)
+	GetByUserFromDB(ctx context.Context, userID string) ([]models.UserRole, error)
+	GetPermissionsByUserFromDB(ctx context.Context, userID string) ([]string, error)
+	AssignInDB(ctx context.Context, userID string, roleName string, assignedBy string) error
+	RemoveFromDB(ctx context.Context, userID string, roleName string) error
+}
+
+type roleStore struct {
+	db *sqlx.DB
+}
+
+func NewRoleStore(db *sqlx.DB) RoleStore {
+	return &roleStore{
+		db: db,
+	}
+}
+
+func (s *roleStore) GetAllFromDB(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+
+	// SQL query to get all roles with their permissions
+	query := `
+		SELECT r.role_id, r.name, COALESCE(r.description, '') AS description, r.created_at,
+			COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}') AS permissions
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.role_id
+		LEFT JOIN permissions p ON p.permission_id = rp.permission_id
+		GROUP BY r.role_id
+		ORDER BY r.name
+	`
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		nil,
+		&roles,
+	); err != nil {
+		log.Printf("Error fetching roles from DB: %v", err)
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (s *roleStore) GetByUserFromDB(ctx context.Context, userID string) ([]models.UserRole, error) {
+	var roles []models.UserRole
+
+	// SQL query to get the roles assigned to a user
+	query := `
+		SELECT r.role_id, r.name, ur.assigned_by, ur.assigned_at
+		FROM user_roles ur
+		JOIN roles r ON r.role_id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY r.name
+	`
+
+	fields := []interface{}{
+		userID,
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&roles,
+	); err != nil {
+		log.Printf("Error fetching roles of user with ID %s from DB: %v", userID, err)
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (s *roleStore) GetPermissionsByUserFromDB(ctx context.Context, userID string) ([]string, error) {
+	var permissions []string
+
+	// SQL query to resolve the permissions granted by all roles of a user
+	query := `
+		SELECT DISTINCT p.name
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.permission_id = rp.permission_id
+		WHERE ur.user_id = $1
+		ORDER BY p.name
+	`
+
+	fields := []interface{}{
+		userID,
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&permissions,
+	); err != nil {
+		log.Printf("Error fetching permissions of user with ID %s from DB: %v", userID, err)
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (s *roleStore) AssignInDB(ctx context.Context, userID string, roleName string, assignedBy string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to assign a role by name, refreshing the assignment if it already exists
+	query := `
+		INSERT INTO user_roles (user_id, role_id, assigned_by, assigned_at)
+		SELECT $1, role_id, $3, CURRENT_TIMESTAMP
+		FROM roles
+		WHERE name = $2
+		ON CONFLICT (user_id, role_id)
+		DO UPDATE SET assigned_by = EXCLUDED.assigned_by, assigned_at = EXCLUDED.assigned_at
+		RETURNING role_id
+	`
+
+	fields := []interface{}{
+		userID,
+		roleName,
+		assignedBy,
+	}
+
+	// Execute the query and return the assigned role ID
+	var roleID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&roleID,
+	)
+	if txErr != nil {
+		// If no rows affected (Role Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Role %s not found", roleName)
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, roleName)
+		}
+		// General error
+		log.Printf("Error assigning role in DB: %v", txErr)
+		return fmt.Errorf("failed to assign role %s to user with ID %s: %w", roleName, userID, txErr)
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for user with ID %s: %v", userID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Role %s assigned to user with ID %s", roleName, userID)
+	return nil
+}
+
+func (s *roleStore) RemoveFromDB(ctx context.Context, userID string, roleName string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to remove a role assignment by role name
+	query := `
+		DELETE FROM user_roles ur
+		USING roles r
+		WHERE ur.role_id = r.role_id
+		AND ur.user_id = $1
+		AND r.name = $2
+		RETURNING ur.role_id
+	`
+
+	fields := []interface{}{
+		userID,
+		roleName,
+	}
+
+	// Execute the query and return the removed role ID
+	var roleID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&roleID,
+	)
+	if txErr != nil {
+		// If no rows affected (Assignment Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Role %s not assigned to user with ID %s", roleName, userID)
+			return fmt.Errorf("%w: %s", ErrRoleNotAssigned, roleName)
+		}
+		// General error
+		log.Printf("Error removing role in DB: %v", txErr)
+		return fmt.Errorf("failed to remove role %s from user with ID %s: %w", roleName, userID, txErr)
+	}
+
+	// Commit the transaction if delete was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for user with ID %s: %v", userID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Role %s removed from user with ID %s", roleName, userID)
+	return nil
+}
@@ -0,0 +1,99 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleAssignInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewRoleStore(db)
+	defer db.Close()
+
+	assignQuery := regexp.QuoteMeta(`
+		INSERT INTO user_roles (user_id, role_id, assigned_by, assigned_at)
+		SELECT $1, role_id, $3, CURRENT_TIMESTAMP
+		FROM roles
+		WHERE name = $2
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful assignment",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(assignQuery).
+					WithArgs("user-1", "finance", "admin-1").
+					WillReturnRows(sqlmock.NewRows([]string{"role_id"}).AddRow("role-1"))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name: "Role not found",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(assignQuery).
+					WithArgs("user-1", "finance", "admin-1").
+					WillReturnRows(sqlmock.NewRows([]string{"role_id"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrRoleNotFound,
+		},
+		{
+			name: "Query execution error",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(assignQuery).
+					WithArgs("user-1", "finance", "admin-1").
+					WillReturnError(errors.New("query error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.AssignInDB(context.Background(), "user-1", "finance", "admin-1")
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
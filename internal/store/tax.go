@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+var (
+	ErrTaxRuleNotFound = errors.New("tax rule not found")
+	ErrTaxRuleConflict = errors.New("tax rule was changed by another request")
+)
+
+type TaxStore interface {
+	GetAllFromDB(ctx context.Context, includeSuperseded bool) ([]models.TaxRule, error)
+	GetByIDFromDB(ctx context.Context, ruleID string) (*models.TaxRule, error)
+	GetActiveForRegionFromDB(ctx context.Context, country string, region string) ([]models.TaxRule, error)
+	CreateVersionInDB(ctx context.Context, rule *models.TaxRule) error
+	RetireInDB(ctx context.Context, ruleID string) error
+}
+
+type taxStore struct {
+	db *sqlx.DB
+}
+
+func NewTaxStore(db *sqlx.DB) TaxStore {
+	return &taxStore{
+		db: db,
+	}
+}
+
+func (s *taxStore) GetAllFromDB(ctx context.Context, includeSuperseded bool) ([]models.TaxRule, error) {
+	var rules []models.TaxRule
+
+	// SQL query to get the rules in force, or every version of them
+	query := `
+		SELECT tax_rule_id, country, region, tax_class, rate, inclusive, version, created_by, created_at, superseded_at
+		FROM tax_rules
+		WHERE ($1 OR superseded_at IS NULL)
+		ORDER BY country, region, tax_class, version DESC
+	`
+
+	fields := []interface{}{
+		includeSuperseded,
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&rules,
+	); err != nil {
+		log.Printf("Error fetching tax rules from DB: %v", err)
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (s *taxStore) GetByIDFromDB(ctx context.Context, ruleID string) (*models.TaxRule, error) {
+	var rule models.TaxRule
+
+	// SQL query to get any version of a tax rule by id
+	query := `
+		SELECT tax_rule_id, country, region, tax_class, rate, inclusive, version, created_by, created_at, superseded_at
+		FROM tax_rules
+		WHERE tax_rule_id = $1
+	`
+
+	fields := []interface{}{
+		ruleID,
+	}
+
+	if err := utils.ExecGetQuery(
+		s.db,
+		query,
+		fields,
+		&rule,
+	); err != nil {
+		// If no rows found
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Tax rule with ID %s not found", ruleID)
+			return nil, fmt.Errorf("%w: tax rule with ID %s", ErrTaxRuleNotFound, ruleID)
+		}
+		log.Printf("Error fetching tax rule with ID %s from DB: %v", ruleID, err)
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (s *taxStore) GetActiveForRegionFromDB(ctx context.Context, country string, region string) ([]models.TaxRule, error) {
+	var rules []models.TaxRule
+
+	// SQL query to get the rules in force for a region and for its whole country
+	query := `
+		SELECT tax_rule_id, country, region, tax_class, rate, inclusive, version, created_by, created_at, superseded_at
+		FROM tax_rules
+		WHERE country = $1
+		AND region IN ('', $2)
+		AND superseded_at IS NULL
+	`
+
+	fields := []interface{}{
+		country,
+		region,
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&rules,
+	); err != nil {
+		log.Printf("Error fetching tax rules for %s %s from DB: %v", country, region, err)
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (s *taxStore) CreateVersionInDB(ctx context.Context, rule *models.TaxRule) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to get the next version number of a rule, continuing after retired versions
+	versionQuery := `
+		SELECT COALESCE(MAX(version), 0) + 1
+		FROM tax_rules
+		WHERE country = $1
+		AND region = $2
+		AND tax_class = $3
+	`
+
+	keyFields := []interface{}{
+		rule.Country,
+		rule.Region,
+		rule.TaxClass,
+	}
+
+	var version int
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		versionQuery,
+		keyFields,
+		&version,
+	)
+	if txErr != nil {
+		log.Printf("Error fetching version of tax rule for %s %s %s: %v", rule.Country, rule.Region, rule.TaxClass, txErr)
+		return fmt.Errorf("failed to fetch tax rule version: %w", txErr)
+	}
+
+	// SQL query to take the version in force out of force, keeping it for the orders taxed with it
+	supersedeQuery := `
+		UPDATE tax_rules
+		SET superseded_at = CURRENT_TIMESTAMP
+		WHERE country = $1
+		AND region = $2
+		AND tax_class = $3
+		AND superseded_at IS NULL
+	`
+
+	if _, txErr = utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		supersedeQuery,
+		keyFields,
+	); txErr != nil {
+		log.Printf("Error superseding tax rule for %s %s %s: %v", rule.Country, rule.Region, rule.TaxClass, txErr)
+		return fmt.Errorf("failed to supersede tax rule: %w", txErr)
+	}
+
+	// SQL query to insert the new version of the rule
+	insertQuery := `
+		INSERT INTO tax_rules (tax_rule_id, country, region, tax_class, rate, inclusive, version, created_by, created_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+		RETURNING tax_rule_id, country, region, tax_class, rate, inclusive, version, created_by, created_at, superseded_at
+	`
+
+	insertFields := []interface{}{
+		rule.Country,
+		rule.Region,
+		rule.TaxClass,
+		rule.Rate,
+		rule.Inclusive,
+		version,
+		rule.CreatedBy,
+	}
+
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		insertQuery,
+		insertFields,
+		rule,
+	)
+	if txErr != nil {
+		// Another request created a version of the same rule first
+		if isUniqueViolation(txErr) {
+			return fmt.Errorf("%w: %s %s %s", ErrTaxRuleConflict, rule.Country, rule.Region, rule.TaxClass)
+		}
+		log.Printf("Error adding tax rule for %s %s %s to DB: %v", rule.Country, rule.Region, rule.TaxClass, txErr)
+		return txErr
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for tax rule with ID %s: %v", rule.TaxRuleID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Tax rule with ID %s added as version %d", rule.TaxRuleID, rule.Version)
+	return nil
+}
+
+func (s *taxStore) RetireInDB(ctx context.Context, ruleID string) error {
+	// SQL query to take a rule out of force without a successor; the row stays for past orders
+	query := `
+		UPDATE tax_rules
+		SET superseded_at = CURRENT_TIMESTAMP
+		WHERE tax_rule_id = $1
+		AND superseded_at IS NULL
+		RETURNING tax_rule_id
+	`
+
+	fields := []interface{}{
+		ruleID,
+	}
+
+	var retiredRuleID string
+	if err := utils.ExecGetQuery(
+		s.db,
+		query,
+		fields,
+		&retiredRuleID,
+	); err != nil {
+		// If no rows affected (Rule not found or already out of force)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Tax rule with ID %s not found in force", ruleID)
+			return fmt.Errorf("%w: tax rule with ID %s in force", ErrTaxRuleNotFound, ruleID)
+		}
+		log.Printf("Error retiring tax rule in DB: %v", err)
+		return fmt.Errorf("failed to retire tax rule with ID %s: %w", ruleID, err)
+	}
+
+	log.Printf("Tax rule with ID %s retired successfully", retiredRuleID)
+	return nil
+}
@@ -0,0 +1,236 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxCreateVersionInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewTaxStore(db)
+	defer db.Close()
+
+	versionQuery := regexp.QuoteMeta(`
+		SELECT COALESCE(MAX(version), 0) + 1
+		FROM tax_rules
+		WHERE country = $1
+		AND region = $2
+		AND tax_class = $3
+	`)
+	supersedeQuery := regexp.QuoteMeta(`
+		UPDATE tax_rules
+		SET superseded_at = CURRENT_TIMESTAMP
+		WHERE country = $1
+		AND region = $2
+		AND tax_class = $3
+		AND superseded_at IS NULL
+	`)
+	insertQuery := regexp.QuoteMeta(`
+		INSERT INTO tax_rules (tax_rule_id, country, region, tax_class, rate, inclusive, version, created_by, created_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+		RETURNING tax_rule_id, country, region, tax_class, rate, inclusive, version, created_by, created_at, superseded_at
+	`)
+
+	// Create test data
+	now := time.Now()
+	adminID := "admin-1"
+	newRule := func() *models.TaxRule {
+		return &models.TaxRule{
+			Country:   "US",
+			Region:    "CA",
+			TaxClass:  "standard",
+			Rate:      mustParseRate(t, "0.0825"),
+			CreatedBy: &adminID,
+		}
+	}
+	ruleColumns := []string{
+		"tax_rule_id",
+		"country",
+		"region",
+		"tax_class",
+		"rate",
+		"inclusive",
+		"version",
+		"created_by",
+		"created_at",
+		"superseded_at",
+	}
+
+	// Write testcases
+	tests := []struct {
+		name          string
+		mock          func()
+		expectErr     bool
+		expectErrIs   error
+		expectID      string
+		expectVersion int
+	}{
+		{
+			name: "Successful new version",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(versionQuery).
+					WithArgs("US", "CA", "standard").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+
+				// The version in force is kept, only taken out of force
+				mock.ExpectExec(supersedeQuery).
+					WithArgs("US", "CA", "standard").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectQuery(insertQuery).
+					WithArgs("US", "CA", "standard", "0.0825", false, 3, "admin-1").
+					WillReturnRows(
+						sqlmock.NewRows(ruleColumns).
+							AddRow("rule-3", "US", "CA", "standard", "0.08250000", false, 3, "admin-1", now, nil),
+					)
+
+				mock.ExpectCommit()
+			},
+			expectErr:     false,
+			expectID:      "rule-3",
+			expectVersion: 3,
+		},
+		{
+			name: "Concurrent new version",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(versionQuery).
+					WithArgs("US", "CA", "standard").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+				mock.ExpectExec(supersedeQuery).
+					WithArgs("US", "CA", "standard").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				mock.ExpectQuery(insertQuery).
+					WithArgs("US", "CA", "standard", "0.0825", false, 3, "admin-1").
+					WillReturnError(&pq.Error{Code: "23505"})
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrTaxRuleConflict,
+		},
+		{
+			name: "Error starting transaction",
+			mock: func() {
+				// Simulate error when starting transaction
+				mock.ExpectBegin().WillReturnError(errors.New("failed to start transaction"))
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			rule := newRule()
+			err := s.CreateVersionInDB(context.Background(), rule)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectID, rule.TaxRuleID)
+				assert.Equal(t, tt.expectVersion, rule.Version)
+				assert.Nil(t, rule.SupersededAt)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTaxRetireInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewTaxStore(db)
+	defer db.Close()
+
+	retireQuery := regexp.QuoteMeta(`
+		UPDATE tax_rules
+		SET superseded_at = CURRENT_TIMESTAMP
+		WHERE tax_rule_id = $1
+		AND superseded_at IS NULL
+		RETURNING tax_rule_id
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful retire",
+			mock: func() {
+				mock.ExpectQuery(retireQuery).
+					WithArgs("rule-1").
+					WillReturnRows(sqlmock.NewRows([]string{"tax_rule_id"}).AddRow("rule-1"))
+			},
+			expectErr: false,
+		},
+		{
+			name: "Rule not in force",
+			mock: func() {
+				mock.ExpectQuery(retireQuery).
+					WithArgs("rule-1").
+					WillReturnRows(sqlmock.NewRows([]string{"tax_rule_id"}))
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrTaxRuleNotFound,
+		},
+		{
+			name: "Query execution error",
+			mock: func() {
+				mock.ExpectQuery(retireQuery).
+					WithArgs("rule-1").
+					WillReturnError(errors.New("query error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.RetireInDB(context.Background(), "rule-1")
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,477 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+type TokenStore interface {
+	CreateRefreshTokenInDB(ctx context.Context, token *models.RefreshToken) (string, error)
+	RotateRefreshTokenInDB(ctx context.Context, tokenHash string, newTokenHash string, expiresAt time.Time) (*models.RefreshToken, error)
+	RevokeRefreshTokenFamilyInDB(ctx context.Context, tokenHash string) error
+	// RevokeAllTokensInDB bumps the user's token version and revokes their refresh tokens in one transaction
+	RevokeAllTokensInDB(ctx context.Context, userID string) error
+	RevokeAccessTokenInDB(ctx context.Context, jti string, userID string, expiresAt time.Time) error
+	IsAccessTokenRevokedFromDB(ctx context.Context, jti string) (bool, error)
+}
+
+type tokenStore struct {
+	db *sqlx.DB
+}
+
+func NewTokenStore(db *sqlx.DB) TokenStore {
+	return &tokenStore{
+		db: db,
+	}
+}
+
+func (s *tokenStore) CreateRefreshTokenInDB(ctx context.Context, token *models.RefreshToken) (string, error) {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return "", fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	tokenID, txErr := s.insertRefreshToken(tx, token)
+	if txErr != nil {
+		return "", txErr
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for refresh token with ID %s: %v", tokenID, txErr)
+		return "", fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success and return the added token ID
+	log.Printf("Refresh token with ID %s added successfully", tokenID)
+	return tokenID, nil
+}
+
+func (s *tokenStore) RotateRefreshTokenInDB(ctx context.Context, tokenHash string, newTokenHash string, expiresAt time.Time) (*models.RefreshToken, error) {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to lock the presented refresh token
+	lockQuery := `
+		SELECT token_id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	var current models.RefreshToken
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		lockQuery,
+		[]interface{}{tokenHash},
+		&current,
+	)
+	if txErr != nil {
+		// If no rows found
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Println("Refresh token not found")
+			return nil, ErrRefreshTokenInvalid
+		}
+		log.Printf("Error fetching refresh token from DB: %v", txErr)
+		return nil, txErr
+	}
+
+	// A revoked token being presented again means it was stolen:
+	// revoke the whole family and keep that change
+	if current.RevokedAt != nil {
+		if txErr = s.revokeFamily(tx, current.FamilyID); txErr != nil {
+			return nil, txErr
+		}
+
+		txErr = tx.Commit()
+		if txErr != nil {
+			log.Printf("Error committing transaction for refresh token family %s: %v", current.FamilyID, txErr)
+			return nil, fmt.Errorf("failed to commit transaction: %w", txErr)
+		}
+
+		log.Printf("Refresh token reuse detected, family %s revoked", current.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		txErr = ErrRefreshTokenExpired
+		return nil, txErr
+	}
+
+	// Issue the successor in the same family
+	next := models.RefreshToken{
+		UserID:    current.UserID,
+		FamilyID:  current.FamilyID,
+		TokenHash: newTokenHash,
+		ExpiresAt: expiresAt,
+	}
+	next.TokenID, txErr = s.insertRefreshToken(tx, &next)
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	// SQL query to retire the presented token
+	revokeQuery := `
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP, replaced_by = $1
+		WHERE token_id = $2
+	`
+
+	if _, txErr = utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		revokeQuery,
+		[]interface{}{next.TokenID, current.TokenID},
+	); txErr != nil {
+		log.Printf("Error revoking refresh token with ID %s: %v", current.TokenID, txErr)
+		return nil, fmt.Errorf("failed to revoke refresh token with ID %s: %w", current.TokenID, txErr)
+	}
+
+	// Commit the transaction if rotation was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for refresh token with ID %s: %v", next.TokenID, txErr)
+		return nil, fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success and return the new token
+	log.Printf("Refresh token with ID %s rotated to %s", current.TokenID, next.TokenID)
+	return &next, nil
+}
+
+func (s *tokenStore) RevokeRefreshTokenFamilyInDB(ctx context.Context, tokenHash string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to find the family of a refresh token
+	query := `
+		SELECT family_id
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	var familyID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{tokenHash},
+		&familyID,
+	)
+	if txErr != nil {
+		// If no rows found
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Println("Refresh token not found")
+			return ErrRefreshTokenInvalid
+		}
+		log.Printf("Error fetching refresh token from DB: %v", txErr)
+		return txErr
+	}
+
+	if txErr = s.revokeFamily(tx, familyID); txErr != nil {
+		return txErr
+	}
+
+	// Commit the transaction if revocation was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for refresh token family %s: %v", familyID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Refresh token family %s revoked successfully", familyID)
+	return nil
+}
+
+func (s *tokenStore) RevokeAllTokensInDB(ctx context.Context, userID string) error {
+	// Begin a transaction so the access and refresh tokens are revoked together
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to invalidate every access token issued so far
+	query := `
+		UPDATE users
+		SET token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1
+		RETURNING token_version
+	`
+
+	// Execute the query and return the new token version
+	var tokenVersion int
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{userID},
+		&tokenVersion,
+	)
+	if txErr != nil {
+		// If no rows affected (User Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("User with ID %s not found", userID)
+			return fmt.Errorf("%w: user with ID %s", ErrUserNotFound, userID)
+		}
+		// General error
+		log.Printf("Error updating token version in DB: %v", txErr)
+		return fmt.Errorf("failed to update token version of user with ID %s: %w", userID, txErr)
+	}
+
+	// SQL query to revoke every live refresh token of a user, so none can mint a token at the new version
+	query = `
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1
+		AND revoked_at IS NULL
+	`
+
+	if _, txErr = utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{userID},
+	); txErr != nil {
+		log.Printf("Error revoking refresh tokens of user with ID %s: %v", userID, txErr)
+		return fmt.Errorf("failed to revoke refresh tokens of user with ID %s: %w", userID, txErr)
+	}
+
+	// Commit the transaction if revocation was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for user with ID %s: %v", userID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Tokens of user with ID %s revoked, token version bumped to %d", userID, tokenVersion)
+	return nil
+}
+
+func (s *tokenStore) RevokeAccessTokenInDB(ctx context.Context, jti string, userID string, expiresAt time.Time) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to add a token to the denylist
+	revokeQuery := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	if _, txErr = utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		revokeQuery,
+		[]interface{}{jti, userID, expiresAt},
+	); txErr != nil {
+		log.Printf("Error revoking access token %s: %v", jti, txErr)
+		return fmt.Errorf("failed to revoke access token %s: %w", jti, txErr)
+	}
+
+	// SQL query to drop entries for tokens that have expired on their own
+	cleanupQuery := `
+		DELETE FROM revoked_tokens
+		WHERE expires_at < CURRENT_TIMESTAMP
+	`
+
+	if _, txErr = utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		cleanupQuery,
+		nil,
+	); txErr != nil {
+		log.Printf("Error cleaning up revoked tokens: %v", txErr)
+		return fmt.Errorf("failed to clean up revoked tokens: %w", txErr)
+	}
+
+	// Commit the transaction if revocation was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for access token %s: %v", jti, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Access token %s revoked successfully", jti)
+	return nil
+}
+
+func (s *tokenStore) IsAccessTokenRevokedFromDB(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+
+	// SQL query to check the denylist for a token
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM revoked_tokens
+			WHERE jti = $1
+		)
+	`
+
+	fields := []interface{}{
+		jti,
+	}
+
+	if err := utils.ExecGetQuery(
+		s.db,
+		query,
+		fields,
+		&revoked,
+	); err != nil {
+		log.Printf("Error checking revocation of access token %s: %v", jti, err)
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+// Insert a refresh token inside an open transaction, starting a new family if none is set
+func (s *tokenStore) insertRefreshToken(tx *sqlx.Tx, token *models.RefreshToken) (string, error) {
+	// SQL query to insert a new refresh token
+	query := `
+		INSERT INTO refresh_tokens (token_id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES (gen_random_uuid(), $1, COALESCE(NULLIF($2, '')::uuid, gen_random_uuid()), $3, $4, CURRENT_TIMESTAMP)
+		RETURNING token_id
+	`
+
+	fields := []interface{}{
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+	}
+
+	var tokenID string
+	if err := utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&tokenID,
+	); err != nil {
+		log.Printf("Error adding refresh token for user with ID %s to DB: %v", token.UserID, err)
+		return "", err
+	}
+
+	return tokenID, nil
+}
+
+// Revoke every live token of a family inside an open transaction
+func (s *tokenStore) revokeFamily(tx *sqlx.Tx, familyID string) error {
+	// SQL query to revoke a refresh token family
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE family_id = $1
+		AND revoked_at IS NULL
+	`
+
+	if _, err := utils.ExecTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{familyID},
+	); err != nil {
+		log.Printf("Error revoking refresh token family %s: %v", familyID, err)
+		return fmt.Errorf("failed to revoke refresh token family %s: %w", familyID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,276 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateRefreshTokenInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewTokenStore(db)
+	defer db.Close()
+
+	lockQuery := regexp.QuoteMeta(`
+		SELECT token_id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`)
+	insertQuery := regexp.QuoteMeta(`
+		INSERT INTO refresh_tokens (token_id, user_id, family_id, token_hash, expires_at, created_at)
+	`)
+	revokeQuery := regexp.QuoteMeta(`
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP, replaced_by = $1
+		WHERE token_id = $2
+	`)
+	revokeFamilyQuery := regexp.QuoteMeta(`
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE family_id = $1
+		AND revoked_at IS NULL
+	`)
+
+	// Create test data
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	columns := []string{
+		"token_id",
+		"user_id",
+		"family_id",
+		"token_hash",
+		"expires_at",
+		"revoked_at",
+		"replaced_by",
+		"created_at",
+	}
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful rotation",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).WithArgs("old-hash").WillReturnRows(
+					sqlmock.NewRows(columns).
+						AddRow("token-1", "user-1", "family-1", "old-hash", expiresAt, nil, nil, now),
+				)
+
+				mock.ExpectQuery(insertQuery).
+					WithArgs("user-1", "family-1", "new-hash", expiresAt).
+					WillReturnRows(sqlmock.NewRows([]string{"token_id"}).AddRow("token-2"))
+
+				mock.ExpectExec(revokeQuery).
+					WithArgs("token-2", "token-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name: "Reused token revokes the family",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).WithArgs("old-hash").WillReturnRows(
+					sqlmock.NewRows(columns).
+						AddRow("token-1", "user-1", "family-1", "old-hash", expiresAt, now, "token-2", now),
+				)
+
+				mock.ExpectExec(revokeFamilyQuery).
+					WithArgs("family-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				// The family revocation must survive the failed refresh
+				mock.ExpectCommit()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrRefreshTokenReused,
+		},
+		{
+			name: "Expired token",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).WithArgs("old-hash").WillReturnRows(
+					sqlmock.NewRows(columns).
+						AddRow("token-1", "user-1", "family-1", "old-hash", now.Add(-time.Hour), nil, nil, now),
+				)
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrRefreshTokenExpired,
+		},
+		{
+			name: "Unknown token",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).WithArgs("old-hash").WillReturnRows(
+					sqlmock.NewRows(columns),
+				)
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrRefreshTokenInvalid,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			token, err := s.RotateRefreshTokenInDB(context.Background(), "old-hash", "new-hash", expiresAt)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, token)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "token-2", token.TokenID)
+				assert.Equal(t, "family-1", token.FamilyID)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeAllTokensInDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewTokenStore(db)
+	defer db.Close()
+
+	versionQuery := regexp.QuoteMeta(`
+		UPDATE users
+		SET token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1
+		RETURNING token_version
+	`)
+	revokeQuery := regexp.QuoteMeta(`
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1
+		AND revoked_at IS NULL
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Version bump and refresh tokens commit together",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(versionQuery).
+					WithArgs("user-1").
+					WillReturnRows(sqlmock.NewRows([]string{"token_version"}).AddRow(4))
+				mock.ExpectExec(revokeQuery).
+					WithArgs("user-1").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name: "Failed refresh token revocation rolls back the version bump",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(versionQuery).
+					WithArgs("user-1").
+					WillReturnRows(sqlmock.NewRows([]string{"token_version"}).AddRow(4))
+				mock.ExpectExec(revokeQuery).
+					WithArgs("user-1").
+					WillReturnError(errors.New("update error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+		{
+			name: "User not found",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(versionQuery).
+					WithArgs("user-1").
+					WillReturnRows(sqlmock.NewRows([]string{"token_version"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrUserNotFound,
+		},
+		{
+			name: "Failed commit",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(versionQuery).
+					WithArgs("user-1").
+					WillReturnRows(sqlmock.NewRows([]string{"token_version"}).AddRow(4))
+				mock.ExpectExec(revokeQuery).
+					WithArgs("user-1").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+
+				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.RevokeAllTokensInDB(context.Background(), "user-1")
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -12,6 +12,10 @@ import (
 	"github.com/officiallysidsingh/ecom-server/internal/utils"
 )
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+)
+
 type UserStore interface {
 	GetByEmailFromDB(ctx context.Context, email string) (*models.User, error)
 	GetByIdFromDB(ctx context.Context, userID string) (*models.User, error)
@@ -33,7 +37,7 @@ func (s *userStore) GetByEmailFromDB(ctx context.Context, userEmail string) (*mo
 
 	// SQL query to get user by email
 	query := `
-		SELECT user_id, name, email, password, role
+		SELECT user_id, name, email, password, role, token_version
 		FROM users
 		WHERE email = $1
 	`
@@ -65,7 +69,7 @@ func (s *userStore) GetByIdFromDB(ctx context.Context, userID string) (*models.U
 
 	// SQL query to get user by email
 	query := `
-		SELECT user_id, name, email, role
+		SELECT user_id, name, email, role, token_version
 		FROM users
 		WHERE user_id = $1
 	`
@@ -83,7 +87,7 @@ func (s *userStore) GetByIdFromDB(ctx context.Context, userID string) (*models.U
 		// If no rows found
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Printf("User with ID %s not found", userID)
-			return nil, fmt.Errorf("user with ID %s not found", userID)
+			return nil, fmt.Errorf("%w: user with ID %s", ErrUserNotFound, userID)
 		}
 		log.Printf("Error fetching user with Email %s from DB: %v", userID, err)
 		return nil, err
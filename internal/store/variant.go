@@ -0,0 +1,334 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+var (
+	ErrVariantNotFound     = errors.New("variant not found")
+	ErrSKUExists           = errors.New("sku already exists")
+	ErrDefaultVariantFixed = errors.New("default variant cannot be deleted")
+)
+
+type VariantStore interface {
+	GetByProductFromDB(ctx context.Context, productID string) ([]models.ProductVariant, error)
+	CreateInDB(ctx context.Context, variant *models.ProductVariant) (string, error)
+	UpdateInDB(ctx context.Context, variant *models.ProductVariant, productID string, variantID string) error
+	DeleteFromDB(ctx context.Context, productID string, variantID string) error
+}
+
+type variantStore struct {
+	db *sqlx.DB
+}
+
+func NewVariantStore(db *sqlx.DB) VariantStore {
+	return &variantStore{
+		db: db,
+	}
+}
+
+func (s *variantStore) GetByProductFromDB(ctx context.Context, productID string) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+
+	// SQL query to get the variants of a product, default first
+	query := `
+		SELECT v.variant_id, v.product_id, v.sku, v.options, v.price, COALESCE(v.price, p.price) AS effective_price,
+			v.stock, v.is_default, v.created_at, v.updated_at
+		FROM product_variants v
+		JOIN products p ON p.product_id = v.product_id
+		WHERE v.product_id = $1
+		ORDER BY v.is_default DESC, v.sku
+	`
+
+	fields := []interface{}{
+		productID,
+	}
+
+	if err := utils.ExecSelectQuery(
+		s.db,
+		query,
+		fields,
+		&variants,
+	); err != nil {
+		log.Printf("Error fetching variants of product with ID %s from DB: %v", productID, err)
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+func (s *variantStore) CreateInDB(ctx context.Context, variant *models.ProductVariant) (string, error) {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return "", fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// SQL query to add a variant to an existing product that is not archived
+	query := `
+		INSERT INTO product_variants (variant_id, product_id, sku, options, price, stock, is_default, created_at, updated_at)
+		SELECT gen_random_uuid(), product_id, $2, $3, $4, $5, FALSE, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		FROM products
+		WHERE product_id = $1
+		AND deleted_at IS NULL
+		RETURNING variant_id
+	`
+
+	fields := []interface{}{
+		variant.ProductID,
+		variant.SKU,
+		variant.Options,
+		variant.Price,
+		variant.Stock,
+	}
+
+	// Execute the query and return the added variant ID
+	var variantID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&variantID,
+	)
+	if txErr != nil {
+		// If no rows affected (Product Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Product with ID %s not found", variant.ProductID)
+			return "", fmt.Errorf("%w: product with ID %s", ErrProductNotFound, variant.ProductID)
+		}
+		if isUniqueViolation(txErr) {
+			return "", fmt.Errorf("%w: %s", ErrSKUExists, variant.SKU)
+		}
+		// General error
+		log.Printf("Error adding variant with SKU %s to DB: %v", variant.SKU, txErr)
+		return "", txErr
+	}
+
+	// Commit the transaction if insert was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for variant with ID %s: %v", variantID, txErr)
+		return "", fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success and return the added variant ID
+	log.Printf("Variant with ID %s added successfully", variantID)
+	return variantID, nil
+}
+
+func (s *variantStore) UpdateInDB(ctx context.Context, variant *models.ProductVariant, productID string, variantID string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// Archived products are read-only
+	if txErr = s.lockActiveProduct(tx, productID); txErr != nil {
+		return txErr
+	}
+
+	// SQL query to update a variant of a product
+	query := `
+		UPDATE product_variants
+		SET sku = $1, options = $2, price = $3, stock = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = $5
+		AND variant_id = $6
+		RETURNING variant_id
+	`
+
+	fields := []interface{}{
+		variant.SKU,
+		variant.Options,
+		variant.Price,
+		variant.Stock,
+		productID,
+		variantID,
+	}
+
+	// Execute the query and return the updated variant ID
+	var updatedVariantID string
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&updatedVariantID,
+	)
+	if txErr != nil {
+		// If no rows affected (Variant Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Variant with ID %s of product with ID %s not found", variantID, productID)
+			return fmt.Errorf("%w: variant with ID %s", ErrVariantNotFound, variantID)
+		}
+		if isUniqueViolation(txErr) {
+			return fmt.Errorf("%w: %s", ErrSKUExists, variant.SKU)
+		}
+		// General error
+		log.Printf("Error updating variant in DB: %v", txErr)
+		return fmt.Errorf("failed to update variant with ID %s: %w", variantID, txErr)
+	}
+
+	// Commit the transaction if update was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for variant with ID %s: %v", variantID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Variant with ID %s updated successfully", updatedVariantID)
+	return nil
+}
+
+func (s *variantStore) DeleteFromDB(ctx context.Context, productID string, variantID string) error {
+	// Begin a transaction to ensure atomicity
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+
+	// For error handling in the deferred rollback
+	var txErr error
+
+	// Ensure transaction is properly rolled back in case of failure
+	defer func() {
+		if txErr != nil {
+			// If error occurs, we rollback the transaction
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	// Archived products are read-only
+	if txErr = s.lockActiveProduct(tx, productID); txErr != nil {
+		return txErr
+	}
+
+	// SQL query to delete a variant, returning whether it was the default one
+	query := `
+		DELETE FROM product_variants
+		WHERE product_id = $1
+		AND variant_id = $2
+		RETURNING is_default
+	`
+
+	fields := []interface{}{
+		productID,
+		variantID,
+	}
+
+	// Execute the query and check which variant was deleted
+	var isDefault bool
+	txErr = utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		fields,
+		&isDefault,
+	)
+	if txErr != nil {
+		// If no rows affected (Variant Not Found)
+		if errors.Is(txErr, sql.ErrNoRows) {
+			log.Printf("Variant with ID %s of product with ID %s not found", variantID, productID)
+			return fmt.Errorf("%w: variant with ID %s", ErrVariantNotFound, variantID)
+		}
+		// General error
+		log.Printf("Error deleting variant in DB: %v", txErr)
+		return fmt.Errorf("failed to delete variant with ID %s: %w", variantID, txErr)
+	}
+
+	// Products without a variant could no longer be ordered, so undo the delete
+	if isDefault {
+		txErr = fmt.Errorf("%w: variant with ID %s", ErrDefaultVariantFixed, variantID)
+		return txErr
+	}
+
+	// Commit the transaction if delete was successful
+	txErr = tx.Commit()
+	if txErr != nil {
+		log.Printf("Error committing transaction for variant with ID %s: %v", variantID, txErr)
+		return fmt.Errorf("failed to commit transaction: %w", txErr)
+	}
+
+	// Log the success
+	log.Printf("Variant with ID %s deleted successfully", variantID)
+	return nil
+}
+
+// Lock a product that is not archived, so it can't be archived while its variants change
+func (s *variantStore) lockActiveProduct(tx *sqlx.Tx, productID string) error {
+	// SQL query to lock a product unless it is archived
+	query := `
+		SELECT product_id
+		FROM products
+		WHERE product_id = $1
+		AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	var lockedProductID string
+	if err := utils.ExecGetTransactionQuery(
+		s.db,
+		tx,
+		query,
+		[]interface{}{productID},
+		&lockedProductID,
+	); err != nil {
+		// If no rows found (Product missing or archived)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Product with ID %s not found", productID)
+			return fmt.Errorf("%w: product with ID %s", ErrProductNotFound, productID)
+		}
+		log.Printf("Error locking product with ID %s: %v", productID, err)
+		return fmt.Errorf("failed to lock product with ID %s: %w", productID, err)
+	}
+
+	return nil
+}
+
+// Report whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
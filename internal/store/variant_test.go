@@ -0,0 +1,151 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariantDeleteFromDB(t *testing.T) {
+	// Create a new mock database connection
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	// Wrap mockDB connection with sqlx
+	db := sqlx.NewDb(mockDB, "postgres")
+	s := store.NewVariantStore(db)
+	defer db.Close()
+
+	lockQuery := regexp.QuoteMeta(`
+		SELECT product_id
+		FROM products
+		WHERE product_id = $1
+		AND deleted_at IS NULL
+		FOR UPDATE
+	`)
+	deleteQuery := regexp.QuoteMeta(`
+		DELETE FROM product_variants
+		WHERE product_id = $1
+		AND variant_id = $2
+		RETURNING is_default
+	`)
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		mock        func()
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name: "Successful delete",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).
+					WithArgs("prod-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow("prod-1"))
+
+				mock.ExpectQuery(deleteQuery).
+					WithArgs("prod-1", "var-2").
+					WillReturnRows(sqlmock.NewRows([]string{"is_default"}).AddRow(false))
+
+				mock.ExpectCommit()
+			},
+			expectErr: false,
+		},
+		{
+			name: "Default variant is kept",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).
+					WithArgs("prod-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow("prod-1"))
+
+				mock.ExpectQuery(deleteQuery).
+					WithArgs("prod-1", "var-2").
+					WillReturnRows(sqlmock.NewRows([]string{"is_default"}).AddRow(true))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrDefaultVariantFixed,
+		},
+		{
+			name: "Variant not found",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).
+					WithArgs("prod-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow("prod-1"))
+
+				mock.ExpectQuery(deleteQuery).
+					WithArgs("prod-1", "var-2").
+					WillReturnRows(sqlmock.NewRows([]string{"is_default"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrVariantNotFound,
+		},
+		{
+			name: "Archived product",
+			mock: func() {
+				mock.ExpectBegin()
+
+				// Archived products are read-only, so the variant is never touched
+				mock.ExpectQuery(lockQuery).
+					WithArgs("prod-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}))
+
+				mock.ExpectRollback()
+			},
+			expectErr:   true,
+			expectErrIs: store.ErrProductNotFound,
+		},
+		{
+			name: "Query execution error",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(lockQuery).
+					WithArgs("prod-1").
+					WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow("prod-1"))
+
+				mock.ExpectQuery(deleteQuery).
+					WithArgs("prod-1", "var-2").
+					WillReturnError(errors.New("query error"))
+
+				mock.ExpectRollback()
+			},
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.DeleteFromDB(context.Background(), "prod-1", "var-2")
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"database/sql"
 	"log"
 
 	"github.com/jmoiron/sqlx"
@@ -29,3 +30,12 @@ func ExecGetTransactionQuery(db *sqlx.DB, tx *sqlx.Tx, query string, fields []in
 	}
 	return nil
 }
+
+func ExecTransactionQuery(db *sqlx.DB, tx *sqlx.Tx, query string, fields []interface{}) (sql.Result, error) {
+	result, err := tx.Exec(query, fields...)
+	if err != nil {
+		log.Printf("Error executing query: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
@@ -2,9 +2,19 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+)
+
+var (
+	ErrMissingIfMatch = errors.New("request must send an If-Match header with the product ETag")
+	ErrInvalidIfMatch = errors.New("only a single strong ETag is accepted in If-Match")
 )
 
 func RespondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
@@ -38,15 +48,20 @@ func ParseBodyToJSON(w http.ResponseWriter, r *http.Request, model interface{})
 			statusCode = http.StatusBadRequest
 
 		// Syntax error in the Request body
-		case err.(*json.SyntaxError) != nil:
+		case errors.As(err, new(*json.SyntaxError)):
 			errMessage = "Malformed JSON syntax"
 			statusCode = http.StatusBadRequest
 
 		// Incorrect types in the Request body
-		case err.(*json.UnmarshalTypeError) != nil:
+		case errors.As(err, new(*json.UnmarshalTypeError)):
 			errMessage = "Incorrect data type in JSON"
 			statusCode = http.StatusBadRequest
 
+		// Amounts that are not exact to the cent, and rates finer than the DB stores
+		case errors.Is(err, models.ErrInvalidMoney), errors.Is(err, models.ErrInvalidRate):
+			errMessage = err.Error()
+			statusCode = http.StatusBadRequest
+
 		// Target struct passed directly
 		case reflect.TypeOf(model).Kind() != reflect.Ptr:
 			errMessage = "Target struct must be a pointer"
@@ -63,3 +78,40 @@ func ParseBodyToJSON(w http.ResponseWriter, r *http.Request, model interface{})
 
 	return "", http.StatusOK, nil
 }
+
+// FormatVersionETag renders a resource version as a strong ETag
+func FormatVersionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ParseIfMatch reads the version from a request's If-Match header.
+// Only a single strong ETag is accepted, since writes must name the exact version they were based on.
+func ParseIfMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, ErrMissingIfMatch
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, ErrInvalidIfMatch
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil {
+		return 0, ErrInvalidIfMatch
+	}
+	return version, nil
+}
+
+// IfNoneMatch reports whether a request's If-None-Match header matches etag, using weak comparison
+func IfNoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package utils_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Build an image of the given bounds filled with one colour
+func filledImage(bounds image.Rectangle, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestResizeToFit(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+
+	// Left half black, right half white, to check pixels are averaged and not just sampled
+	halves := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x >= 2 {
+				halves.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				halves.SetRGBA(x, y, color.RGBA{A: 255})
+			}
+		}
+	}
+
+	// A paletted image is converted to RGBA first
+	paletted := image.NewPaletted(image.Rect(0, 0, 300, 100), color.Palette{red})
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		src         image.Image
+		maxEdge     int
+		expectSize  image.Point
+		expectColor *color.RGBA
+	}{
+		{
+			name:        "Landscape keeps its aspect ratio",
+			src:         filledImage(image.Rect(0, 0, 800, 600), red),
+			maxEdge:     400,
+			expectSize:  image.Pt(400, 300),
+			expectColor: &red,
+		},
+		{
+			name:       "Portrait keeps its aspect ratio",
+			src:        filledImage(image.Rect(0, 0, 600, 800), red),
+			maxEdge:    400,
+			expectSize: image.Pt(300, 400),
+		},
+		{
+			name:       "Odd sizes round to the nearest pixel",
+			src:        filledImage(image.Rect(0, 0, 333, 101), red),
+			maxEdge:    150,
+			expectSize: image.Pt(150, 45),
+		},
+		{
+			name:       "Extreme aspect ratio keeps at least one pixel",
+			src:        filledImage(image.Rect(0, 0, 1000, 1), red),
+			maxEdge:    10,
+			expectSize: image.Pt(10, 1),
+		},
+		{
+			name:       "Square image",
+			src:        filledImage(image.Rect(0, 0, 500, 500), red),
+			maxEdge:    150,
+			expectSize: image.Pt(150, 150),
+		},
+		{
+			name:        "Smaller image is not upscaled",
+			src:         filledImage(image.Rect(0, 0, 120, 80), red),
+			maxEdge:     400,
+			expectSize:  image.Pt(120, 80),
+			expectColor: &red,
+		},
+		{
+			name:       "Image exactly at the limit is not resized",
+			src:        filledImage(image.Rect(0, 0, 400, 200), red),
+			maxEdge:    400,
+			expectSize: image.Pt(400, 200),
+		},
+		{
+			name:        "Non-zero origin",
+			src:         filledImage(image.Rect(50, 30, 850, 630), red),
+			maxEdge:     400,
+			expectSize:  image.Pt(400, 300),
+			expectColor: &red,
+		},
+		{
+			name:        "Non-zero origin smaller than the limit",
+			src:         filledImage(image.Rect(-20, -10, 80, 40), red),
+			maxEdge:     400,
+			expectSize:  image.Pt(100, 50),
+			expectColor: &red,
+		},
+		{
+			name:        "Sub-image of a larger image",
+			src:         filledImage(image.Rect(0, 0, 1000, 1000), red).SubImage(image.Rect(200, 100, 1000, 700)),
+			maxEdge:     400,
+			expectSize:  image.Pt(400, 300),
+			expectColor: &red,
+		},
+		{
+			name:        "Paletted image",
+			src:         paletted,
+			maxEdge:     150,
+			expectSize:  image.Pt(150, 50),
+			expectColor: &red,
+		},
+		{
+			name:       "Pixels are averaged",
+			src:        halves,
+			maxEdge:    2,
+			expectSize: image.Pt(2, 1),
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := utils.ResizeToFit(tt.src, tt.maxEdge)
+
+			// Results always start at the origin
+			assert.Equal(t, image.Point{}, dst.Bounds().Min)
+			assert.Equal(t, tt.expectSize, dst.Bounds().Size())
+			if tt.expectColor != nil {
+				size := dst.Bounds().Size()
+				for _, p := range []image.Point{{0, 0}, {size.X - 1, size.Y - 1}, {size.X / 2, size.Y / 2}} {
+					assert.Equal(t, *tt.expectColor, dst.RGBAAt(p.X, p.Y), "pixel at %v", p)
+				}
+			}
+		})
+	}
+
+	// Each target pixel of the halves image covers a single colour
+	dst := utils.ResizeToFit(halves, 2)
+	assert.Equal(t, color.RGBA{A: 255}, dst.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, dst.RGBAAt(1, 0))
+
+	// Shrinking further blends them
+	dst = utils.ResizeToFit(halves, 1)
+	assert.Equal(t, color.RGBA{R: 128, G: 128, B: 128, A: 255}, dst.RGBAAt(0, 0))
+}
+
+func TestDecodeImage(t *testing.T) {
+	src := filledImage(image.Rect(0, 0, 40, 30), color.RGBA{G: 255, A: 255})
+
+	encode := func(fn func(*bytes.Buffer) error) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, fn(&buf))
+		return buf.Bytes()
+	}
+	pngData := encode(func(buf *bytes.Buffer) error { return png.Encode(buf, src) })
+	jpegData := encode(func(buf *bytes.Buffer) error { return jpeg.Encode(buf, src, nil) })
+	gifData := encode(func(buf *bytes.Buffer) error { return gif.Encode(buf, src, nil) })
+
+	// Write testcases
+	tests := []struct {
+		name         string
+		data         []byte
+		maxPixels    int
+		expectErr    bool
+		expectErrIs  error
+		expectFormat string
+	}{
+		{
+			name:         "PNG",
+			data:         pngData,
+			maxPixels:    10_000,
+			expectFormat: "png",
+		},
+		{
+			name:         "JPEG",
+			data:         jpegData,
+			maxPixels:    10_000,
+			expectFormat: "jpeg",
+		},
+		{
+			name:         "GIF",
+			data:         gifData,
+			maxPixels:    10_000,
+			expectFormat: "gif",
+		},
+		{
+			name:         "Exactly at the pixel limit",
+			data:         pngData,
+			maxPixels:    1_200,
+			expectFormat: "png",
+		},
+		{
+			name:        "Too many pixels",
+			data:        pngData,
+			maxPixels:   1_199,
+			expectErr:   true,
+			expectErrIs: utils.ErrImageTooLarge,
+		},
+		{
+			name:      "Not an image",
+			data:      []byte("definitely not an image"),
+			maxPixels: 10_000,
+			expectErr: true,
+		},
+		{
+			name:      "Truncated image",
+			data:      pngData[:len(pngData)/2],
+			maxPixels: 10_000,
+			expectErr: true,
+		},
+		{
+			name:      "Empty data",
+			data:      nil,
+			maxPixels: 10_000,
+			expectErr: true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, format, err := utils.DecodeImage(tt.data, tt.maxPixels)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+				assert.Nil(t, img)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectFormat, format)
+				assert.Equal(t, image.Pt(40, 30), img.Bounds().Size())
+			}
+		})
+	}
+}
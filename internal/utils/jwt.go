@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -11,18 +15,27 @@ import (
 )
 
 var (
-	ErrGeneratingToken = errors.New("error generating token")
+	ErrGeneratingToken        = errors.New("error generating token")
+	ErrGeneratingRefreshToken = errors.New("error generating refresh token")
 )
 
-// GenerateJWT creates a new JWT token
-func GenerateJWT(userID string, role string, config config.JWTConfig) (string, error) {
+// GenerateJWT creates a new JWT token signed with the current signing key
+func GenerateJWT(userID string, role string, tokenVersion int, keys *KeySet, config config.JWTConfig) (string, error) {
 	now := time.Now()
 
+	// Unique token ID so a single token can be revoked
+	jti, err := generateTokenID()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrGeneratingToken, err)
+	}
+
 	// Create claims with user data and standard claims
 	claims := models.Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:       userID,
+		Role:         role,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(now.Add(config.TokenExpiration)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -30,14 +43,37 @@ func GenerateJWT(userID string, role string, config config.JWTConfig) (string, e
 		},
 	}
 
-	// Create token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign token with secret key
-	tokenString, err := token.SignedString([]byte(config.SecretKey))
+	// Sign token with the current signing key
+	tokenString, err := keys.Sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("%w: %v", ErrGeneratingToken, err)
 	}
 
 	return tokenString, nil
 }
+
+// GenerateRefreshToken creates a new opaque refresh token and the hash to store for it
+func GenerateRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrGeneratingRefreshToken, err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the hex SHA-256 of a refresh token, so the raw token never hits the DB
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Random hex ID for the jti claim
+func generateTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,378 @@
+package utils_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// PEM encoded keys shared by the tests in this file
+type testKeys struct {
+	rsa       *rsa.PrivateKey
+	ed        ed25519.PrivateKey
+	rsaPKCS1  []byte
+	rsaPKCS8  []byte
+	rsaPublic []byte
+	edPKCS8   []byte
+	edPublic  []byte
+	otherRSA  *rsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	encode := func(blockType string, der []byte, err error) []byte {
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	}
+	rsaPKCS8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	rsaPKCS8PEM := encode("PRIVATE KEY", rsaPKCS8, err)
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	rsaPublicPEM := encode("PUBLIC KEY", rsaPublic, err)
+	edPKCS8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	edPKCS8PEM := encode("PRIVATE KEY", edPKCS8, err)
+	edPublic, err := x509.MarshalPKIXPublicKey(edKey.Public())
+	edPublicPEM := encode("PUBLIC KEY", edPublic, err)
+
+	return testKeys{
+		rsa:       rsaKey,
+		ed:        edKey,
+		rsaPKCS1:  encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), nil),
+		rsaPKCS8:  rsaPKCS8PEM,
+		rsaPublic: rsaPublicPEM,
+		edPKCS8:   edPKCS8PEM,
+		edPublic:  edPublicPEM,
+		otherRSA:  otherRSAKey,
+	}
+}
+
+// Write files into a fresh key directory and load it
+func loadKeySet(t *testing.T, files map[string][]byte, signingKID string) (*utils.KeySet, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+
+	return utils.LoadKeySet(dir, signingKID)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	keys := newTestKeys(t)
+
+	// Write testcases
+	tests := []struct {
+		name          string
+		files         map[string][]byte
+		signingKID    string
+		expectErr     bool
+		expectErrIs   error
+		expectKIDs    []string
+		expectMethods []string
+	}{
+		{
+			name: "RSA and Ed25519 keys with a retired public key",
+			files: map[string][]byte{
+				"rsa-2025.pem":     keys.rsaPKCS1,
+				"ed-2025.pem":      keys.edPKCS8,
+				"retired-2024.pem": keys.edPublic,
+				"README.txt":       []byte("not a key"),
+			},
+			signingKID:    "ed-2025",
+			expectErr:     false,
+			expectKIDs:    []string{"ed-2025", "retired-2024", "rsa-2025"},
+			expectMethods: []string{"EdDSA", "RS256"},
+		},
+		{
+			name: "PKCS#8 RSA key",
+			files: map[string][]byte{
+				"rsa-2025.pem": keys.rsaPKCS8,
+			},
+			signingKID:    "rsa-2025",
+			expectErr:     false,
+			expectKIDs:    []string{"rsa-2025"},
+			expectMethods: []string{"RS256"},
+		},
+		{
+			name: "Kid is the file name without its extension",
+			files: map[string][]byte{
+				"2025.01.primary.pem": keys.rsaPKCS1,
+			},
+			signingKID:    "2025.01.primary",
+			expectErr:     false,
+			expectKIDs:    []string{"2025.01.primary"},
+			expectMethods: []string{"RS256"},
+		},
+		{
+			name: "Signing key not found",
+			files: map[string][]byte{
+				"rsa-2025.pem": keys.rsaPKCS1,
+			},
+			signingKID:  "rsa-2026",
+			expectErr:   true,
+			expectErrIs: utils.ErrUnknownKeyID,
+		},
+		{
+			name:        "Empty key directory",
+			files:       map[string][]byte{},
+			signingKID:  "rsa-2025",
+			expectErr:   true,
+			expectErrIs: utils.ErrUnknownKeyID,
+		},
+		{
+			name: "Signing key without a private key",
+			files: map[string][]byte{
+				"rsa-2025.pem": keys.rsaPublic,
+			},
+			signingKID:  "rsa-2025",
+			expectErr:   true,
+			expectErrIs: utils.ErrSigningKeyNotPrivate,
+		},
+		{
+			name: "Unsupported PEM block",
+			files: map[string][]byte{
+				"rsa-2025.pem": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{0x30}}),
+			},
+			signingKID:  "rsa-2025",
+			expectErr:   true,
+			expectErrIs: utils.ErrUnsupportedKeyType,
+		},
+		{
+			name: "No PEM block",
+			files: map[string][]byte{
+				"rsa-2025.pem": []byte("not a key"),
+			},
+			signingKID: "rsa-2025",
+			expectErr:  true,
+		},
+		{
+			name: "Corrupt key",
+			files: map[string][]byte{
+				"rsa-2025.pem": pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("garbage")}),
+			},
+			signingKID: "rsa-2025",
+			expectErr:  true,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := loadKeySet(t, tt.files, tt.signingKID)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+				assert.Nil(t, ks)
+				return
+			}
+			require.NoError(t, err)
+
+			var kids []string
+			for _, jwk := range ks.JWKS().Keys {
+				kids = append(kids, jwk.KeyID)
+			}
+			assert.Equal(t, tt.expectKIDs, kids)
+			assert.Equal(t, tt.expectMethods, ks.Methods())
+		})
+	}
+}
+
+func TestKeySetSignAndVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	files := map[string][]byte{
+		"rsa-2025.pem": keys.rsaPKCS1,
+		"ed-2025.pem":  keys.edPKCS8,
+	}
+
+	// Write testcases
+	tests := []struct {
+		name       string
+		signingKID string
+		expectAlg  string
+	}{
+		{
+			name:       "RS256 round trip",
+			signingKID: "rsa-2025",
+			expectAlg:  "RS256",
+		},
+		{
+			name:       "EdDSA round trip",
+			signingKID: "ed-2025",
+			expectAlg:  "EdDSA",
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := loadKeySet(t, files, tt.signingKID)
+			require.NoError(t, err)
+
+			signed, err := ks.Sign(jwt.RegisteredClaims{
+				Subject:   "user-1",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			})
+			require.NoError(t, err)
+
+			claims := &jwt.RegisteredClaims{}
+			token, err := jwt.ParseWithClaims(signed, claims, ks.Keyfunc, jwt.WithValidMethods(ks.Methods()))
+			require.NoError(t, err)
+			assert.True(t, token.Valid)
+			assert.Equal(t, tt.signingKID, token.Header["kid"])
+			assert.Equal(t, tt.expectAlg, token.Header["alg"])
+			assert.Equal(t, "user-1", claims.Subject)
+		})
+	}
+}
+
+func TestKeySetKeyfunc(t *testing.T) {
+	keys := newTestKeys(t)
+	ks, err := loadKeySet(t, map[string][]byte{
+		"rsa-2025.pem": keys.rsaPKCS1,
+		"ed-2025.pem":  keys.edPKCS8,
+	}, "rsa-2025")
+	require.NoError(t, err)
+
+	claims := jwt.RegisteredClaims{
+		Subject:   "user-1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+	sign := func(method jwt.SigningMethod, kid interface{}, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	// Write testcases
+	tests := []struct {
+		name        string
+		token       string
+		expectErr   bool
+		expectErrIs error
+	}{
+		{
+			name:      "Valid token",
+			token:     sign(jwt.SigningMethodRS256, "rsa-2025", keys.rsa),
+			expectErr: false,
+		},
+		{
+			name:        "Unknown kid",
+			token:       sign(jwt.SigningMethodRS256, "rsa-2024", keys.rsa),
+			expectErr:   true,
+			expectErrIs: utils.ErrUnknownKeyID,
+		},
+		{
+			name:        "Missing kid",
+			token:       sign(jwt.SigningMethodRS256, nil, keys.rsa),
+			expectErr:   true,
+			expectErrIs: utils.ErrUnknownKeyID,
+		},
+		{
+			name:        "Non-string kid",
+			token:       sign(jwt.SigningMethodRS256, 2025, keys.rsa),
+			expectErr:   true,
+			expectErrIs: utils.ErrUnknownKeyID,
+		},
+		{
+			// An RSA public key must never be used as an HMAC secret
+			name:      "HMAC token naming an RSA key",
+			token:     sign(jwt.SigningMethodHS256, "rsa-2025", x509.MarshalPKCS1PublicKey(&keys.rsa.PublicKey)),
+			expectErr: true,
+		},
+		{
+			name:      "EdDSA token naming an RSA key",
+			token:     sign(jwt.SigningMethodEdDSA, "rsa-2025", keys.ed),
+			expectErr: true,
+		},
+		{
+			name:      "RS384 token naming an RS256 key",
+			token:     sign(jwt.SigningMethodRS384, "rsa-2025", keys.rsa),
+			expectErr: true,
+		},
+		{
+			name:        "Signed by another key under a known kid",
+			token:       sign(jwt.SigningMethodRS256, "rsa-2025", keys.otherRSA),
+			expectErr:   true,
+			expectErrIs: jwt.ErrTokenSignatureInvalid,
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No method allow-list here, so only Keyfunc stands between the token and the key
+			token, err := jwt.Parse(tt.token, ks.Keyfunc)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				if tt.expectErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, token.Valid)
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	keys := newTestKeys(t)
+	ks, err := loadKeySet(t, map[string][]byte{
+		"rsa-2025.pem": keys.rsaPKCS1,
+		"ed-2025.pem":  keys.edPKCS8,
+		"ed-2024.pem":  keys.edPublic,
+	}, "rsa-2025")
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	edPublic := b64(keys.ed.Public().(ed25519.PublicKey))
+
+	// Keys come back sorted by kid, with only their public parts
+	expected := utils.JWKSet{
+		Keys: []utils.JWK{
+			{KeyType: "OKP", KeyID: "ed-2024", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: edPublic},
+			{KeyType: "OKP", KeyID: "ed-2025", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: edPublic},
+			{
+				KeyType:   "RSA",
+				KeyID:     "rsa-2025",
+				Use:       "sig",
+				Algorithm: "RS256",
+				N:         b64(keys.rsa.N.Bytes()),
+				E:         b64(big.NewInt(int64(keys.rsa.E)).Bytes()),
+			},
+		},
+	}
+	assert.Equal(t, expected, ks.JWKS())
+
+	// The exponent is the minimal big-endian encoding, AQAB for 65537
+	assert.Equal(t, "AQAB", ks.JWKS().Keys[2].E)
+}
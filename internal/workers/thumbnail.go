@@ -0,0 +1,177 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/officiallysidsingh/ecom-server/internal/config"
+	"github.com/officiallysidsingh/ecom-server/internal/models"
+	"github.com/officiallysidsingh/ecom-server/internal/storage"
+	"github.com/officiallysidsingh/ecom-server/internal/store"
+	"github.com/officiallysidsingh/ecom-server/internal/utils"
+)
+
+// Content types the standard library can decode; WebP originals get no thumbnails
+var thumbnailSourceTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// ThumbnailQueue accepts images whose thumbnails should be generated
+type ThumbnailQueue interface {
+	Enqueue(image models.ProductImage)
+}
+
+type ThumbnailWorker struct {
+	imageStore store.ImageStore
+	files      storage.Backend
+	config     config.ThumbnailConfig
+	jobs       chan models.ProductImage
+}
+
+func NewThumbnailWorker(imageStore store.ImageStore, files storage.Backend, config config.ThumbnailConfig) *ThumbnailWorker {
+	return &ThumbnailWorker{
+		imageStore: imageStore,
+		files:      files,
+		config:     config,
+		jobs:       make(chan models.ProductImage, config.QueueSize),
+	}
+}
+
+// Enqueue schedules an image without blocking the caller.
+// When the queue is full the image is dropped and picked up again by the next backfill.
+func (w *ThumbnailWorker) Enqueue(image models.ProductImage) {
+	if len(w.config.Sizes) == 0 || !isThumbnailSource(image.ContentType) {
+		return
+	}
+
+	select {
+	case w.jobs <- image:
+	default:
+		log.Printf("Thumbnail queue full, skipping image with ID %s until next start", image.ImageID)
+	}
+}
+
+// Run processes queued images until ctx is cancelled, then waits for the workers to stop
+func (w *ThumbnailWorker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case image := <-w.jobs:
+					if err := w.generate(ctx, image); err != nil {
+						log.Printf("Error generating thumbnails for image with ID %s: %v", image.ImageID, err)
+					}
+				}
+			}
+		}()
+	}
+
+	// Catch up on images uploaded before a restart or before a size was configured
+	w.backfill(ctx)
+
+	wg.Wait()
+}
+
+func (w *ThumbnailWorker) backfill(ctx context.Context) {
+	if len(w.config.Sizes) == 0 {
+		return
+	}
+
+	images, err := w.imageStore.GetMissingThumbnailsFromDB(ctx, w.config.Sizes, thumbnailSourceTypes)
+	if err != nil {
+		log.Printf("Error finding images missing thumbnails: %v", err)
+		return
+	}
+	if len(images) > 0 {
+		log.Printf("Backfilling thumbnails for %d images", len(images))
+	}
+
+	for _, image := range images {
+		select {
+		case <-ctx.Done():
+			return
+		case w.jobs <- image:
+		}
+	}
+}
+
+// Generate every configured size of an image and record it
+func (w *ThumbnailWorker) generate(ctx context.Context, image models.ProductImage) error {
+	original, err := w.files.Open(ctx, image.StorageKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(original)
+	original.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", image.StorageKey, err)
+	}
+
+	src, format, err := utils.DecodeImage(data, w.config.MaxPixels)
+	if err != nil {
+		return err
+	}
+
+	for _, size := range w.config.Sizes {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		thumb := utils.ResizeToFit(src, size)
+
+		// Keep JPEGs as JPEGs; PNG keeps transparency for everything else
+		var buf bytes.Buffer
+		ext, contentType := ".png", "image/png"
+		if format == "jpeg" {
+			ext, contentType = ".jpg", "image/jpeg"
+			err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, thumb)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode %dpx thumbnail: %w", size, err)
+		}
+
+		thumbnail := models.ImageThumbnail{
+			ImageID:    image.ImageID,
+			Size:       size,
+			StorageKey: thumbnailKey(image.StorageKey, size, ext),
+			Width:      thumb.Bounds().Dx(),
+			Height:     thumb.Bounds().Dy(),
+		}
+		if err := w.files.Put(ctx, thumbnail.StorageKey, &buf, contentType); err != nil {
+			return err
+		}
+		if err := w.imageStore.SaveThumbnailInDB(ctx, &thumbnail); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Store thumbnails next to the original, e.g. products/p1/abc.png -> products/p1/abc_400.png
+func thumbnailKey(originalKey string, size int, ext string) string {
+	base := strings.TrimSuffix(originalKey, path.Ext(originalKey))
+	return fmt.Sprintf("%s_%d%s", base, size, ext)
+}
+
+func isThumbnailSource(contentType string) bool {
+	for _, t := range thumbnailSourceTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
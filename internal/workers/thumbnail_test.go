@@ -0,0 +1,61 @@
+package workers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThumbnailKey(t *testing.T) {
+	// Write testcases
+	tests := []struct {
+		name        string
+		originalKey string
+		size        int
+		ext         string
+		expectKey   string
+	}{
+		{
+			name:        "Same extension",
+			originalKey: "products/p1/abc.png",
+			size:        400,
+			ext:         ".png",
+			expectKey:   "products/p1/abc_400.png",
+		},
+		{
+			name:        "Different extension",
+			originalKey: "products/p1/abc.gif",
+			size:        150,
+			ext:         ".png",
+			expectKey:   "products/p1/abc_150.png",
+		},
+		{
+			name:        "Only the last extension is replaced",
+			originalKey: "products/p1/abc.tar.jpg",
+			size:        1200,
+			ext:         ".jpg",
+			expectKey:   "products/p1/abc.tar_1200.jpg",
+		},
+		{
+			name:        "Original without extension",
+			originalKey: "products/p1/abc",
+			size:        400,
+			ext:         ".jpg",
+			expectKey:   "products/p1/abc_400.jpg",
+		},
+		{
+			name:        "Dot in a directory name",
+			originalKey: "products/p.1/abc",
+			size:        400,
+			ext:         ".jpg",
+			expectKey:   "products/p.1/abc_400.jpg",
+		},
+	}
+
+	// Run testcases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectKey, thumbnailKey(tt.originalKey, tt.size, tt.ext))
+		})
+	}
+}